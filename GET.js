@@ -1,4 +0,0 @@
-const { delay, type, file } = require('connect-api-mocker/helpers')
-const path = require('path')
-
-module.exports = [delay(500), type('application/json'), file(path.join(__dirname, './index.json'))]
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+)
+
+// aggregateBuilds combines the builds of all exported pipelines into a single list, most recent first like the api sorts them
+func aggregateBuilds(builds []*contracts.Build) PipelineBuildsListResponse {
+
+	items := append([]*contracts.Build{}, builds...)
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].InsertedAt.Equal(items[j].InsertedAt) {
+			return items[i].InsertedAt.After(items[j].InsertedAt)
+		}
+		return idGreater(items[i].ID, items[j].ID)
+	})
+
+	return PipelineBuildsListResponse{
+		Items:      items,
+		Pagination: singlePage(len(items)),
+	}
+}
+
+// aggregateReleases combines the releases of all exported pipelines into a single list, most recent first like the api sorts them
+func aggregateReleases(releases []*contracts.Release) PipelineReleasesListResponse {
+
+	items := append([]*contracts.Release{}, releases...)
+	sort.SliceStable(items, func(i, j int) bool {
+		insertedAtI, insertedAtJ := releaseInsertedAt(items[i]), releaseInsertedAt(items[j])
+		if !insertedAtI.Equal(insertedAtJ) {
+			return insertedAtI.After(insertedAtJ)
+		}
+		return idGreater(items[i].ID, items[j].ID)
+	})
+
+	return PipelineReleasesListResponse{
+		Items:      items,
+		Pagination: singlePage(len(items)),
+	}
+}
+
+func releaseInsertedAt(release *contracts.Release) time.Time {
+	if release.InsertedAt == nil {
+		return time.Time{}
+	}
+	return *release.InsertedAt
+}
+
+// idGreater orders ids with the same insertion time highest first, numerically if both are numbers
+func idGreater(a, b string) bool {
+	numberA, errA := strconv.ParseInt(a, 10, 64)
+	numberB, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		return numberA > numberB
+	}
+	return a > b
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateBuilds(t *testing.T) {
+	t.Run("SortsBuildsOfAllPipelinesMostRecentFirst", func(t *testing.T) {
+
+		now := time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC)
+		builds := []*contracts.Build{
+			{ID: "1", RepoName: "estafette-ci-api", InsertedAt: now.Add(-2 * time.Hour)},
+			{ID: "2", RepoName: "estafette-ci-api", InsertedAt: now},
+			{ID: "3", RepoName: "estafette-ci-web", InsertedAt: now.Add(-1 * time.Hour)},
+		}
+
+		// act
+		response := aggregateBuilds(builds)
+
+		if assert.Equal(t, 3, len(response.Items)) {
+			assert.Equal(t, "2", response.Items[0].ID)
+			assert.Equal(t, "3", response.Items[1].ID)
+			assert.Equal(t, "1", response.Items[2].ID)
+		}
+		assert.Equal(t, 1, response.Pagination.TotalPages)
+		assert.Equal(t, 3, response.Pagination.TotalItems)
+		assert.Equal(t, "1", builds[0].ID)
+	})
+
+	t.Run("BreaksTiesOnNumericIDHighestFirst", func(t *testing.T) {
+
+		now := time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC)
+		builds := []*contracts.Build{
+			{ID: "9", InsertedAt: now},
+			{ID: "10", InsertedAt: now},
+			{ID: "abc", InsertedAt: now.Add(-1 * time.Hour)},
+			{ID: "abd", InsertedAt: now.Add(-1 * time.Hour)},
+		}
+
+		// act
+		response := aggregateBuilds(builds)
+
+		if assert.Equal(t, 4, len(response.Items)) {
+			assert.Equal(t, "10", response.Items[0].ID)
+			assert.Equal(t, "9", response.Items[1].ID)
+			assert.Equal(t, "abd", response.Items[2].ID)
+			assert.Equal(t, "abc", response.Items[3].ID)
+		}
+	})
+}
+
+func TestAggregateReleases(t *testing.T) {
+	t.Run("SortsReleasesWithoutInsertedAtLast", func(t *testing.T) {
+
+		now := time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC)
+		earlier := now.Add(-1 * time.Hour)
+		releases := []*contracts.Release{
+			{ID: "1"},
+			{ID: "2", InsertedAt: &earlier},
+			{ID: "3", InsertedAt: &now},
+		}
+
+		// act
+		response := aggregateReleases(releases)
+
+		if assert.Equal(t, 3, len(response.Items)) {
+			assert.Equal(t, "3", response.Items[0].ID)
+			assert.Equal(t, "2", response.Items[1].ID)
+			assert.Equal(t, "1", response.Items[2].ID)
+		}
+		assert.Equal(t, 3, response.Pagination.TotalItems)
+	})
+}
@@ -35,6 +35,23 @@ type ApiClient interface {
 	GetPipelineBots(ctx context.Context, token string, pipelinePath string) (response PipelineBotsListResponse, err error)
 	GetPipelineBot(ctx context.Context, token string, pipelineBotPath string) (bot *contracts.Bot, err error)
 	GetPipelineBotLogs(ctx context.Context, token string, pipelineBotPath string) (botLogs PipelineBotsLogsListResponse, err error)
+	GetPipelineBuildBranches(ctx context.Context, token string, pipelinePath string) (response PipelineBuildBranchesListResponse, err error)
+	GetPipelineBotNames(ctx context.Context, token string, pipelinePath string) (response PipelineBotNamesListResponse, err error)
+	GetPipelineWarnings(ctx context.Context, token string, pipelinePath string) (response PipelineWarningsResponse, err error)
+	GetPipelineStatsBuildsDurations(ctx context.Context, token string, pipelinePath string) (response PipelineDurationsResponse, err error)
+	GetPipelineStatsBuildsCPU(ctx context.Context, token string, pipelinePath string) (response PipelineMeasurementsResponse, err error)
+	GetPipelineStatsBuildsMemory(ctx context.Context, token string, pipelinePath string) (response PipelineMeasurementsResponse, err error)
+	GetPipelineStatsReleasesDurations(ctx context.Context, token string, pipelinePath string) (response PipelineDurationsResponse, err error)
+	GetPipelineStatsReleasesCPU(ctx context.Context, token string, pipelinePath string) (response PipelineMeasurementsResponse, err error)
+	GetPipelineStatsReleasesMemory(ctx context.Context, token string, pipelinePath string) (response PipelineMeasurementsResponse, err error)
+	GetCatalogEntities(ctx context.Context, token string, pageNumber, pageSize int, filters map[string][]string) (response CatalogEntitiesListResponse, err error)
+	GetCatalogFilters(ctx context.Context, token string) (filters []string, err error)
+	GetLabels(ctx context.Context, token string, pageNumber, pageSize int) (response LabelsListResponse, err error)
+	GetFrequentLabels(ctx context.Context, token string, pageNumber, pageSize int) (response FrequentLabelsListResponse, err error)
+	GetStatsMostBuilds(ctx context.Context, token string, pageNumber, pageSize int, filters map[string][]string) (response PipelineCountsListResponse, err error)
+	GetStatsMostReleases(ctx context.Context, token string, pageNumber, pageSize int, filters map[string][]string) (response PipelineCountsListResponse, err error)
+	GetReleaseTargets(ctx context.Context, token string, pageNumber, pageSize int) (response ReleaseTargetsListResponse, err error)
+	GetOrganizations(ctx context.Context, token string, pageNumber, pageSize int) (response OrganizationsListResponse, err error)
 	GetBytesResponse(ctx context.Context, token string, path string) (bytes []byte, err error)
 	GetSSEResponse(ctx context.Context, token string, path string, maxNumberOfEvents int) (bytes []byte, err error)
 }
@@ -46,8 +63,33 @@ func NewApiClient(apiBaseURL string) ApiClient {
 	}
 }
 
+// ApiClientOptions configures what an ApiClient keeps track of besides the typed responses
+type ApiClientOptions struct {
+	// SchemaDrift records the fields of every json response unknown to the contracts types, if set
+	SchemaDrift *SchemaDrift
+
+	// RawResponses keeps the raw body of every json response, if set
+	RawResponses *RawResponses
+
+	// HarExchanges keeps the method, status and headers of every request, if set
+	HarExchanges *HarExchanges
+}
+
+// NewApiClientWithOptions returns a new ApiClient keeping track of the responses as configured in options
+func NewApiClientWithOptions(apiBaseURL string, options ApiClientOptions) ApiClient {
+	return &apiClient{
+		apiBaseURL:   apiBaseURL,
+		schemaDrift:  options.SchemaDrift,
+		rawResponses: options.RawResponses,
+		harExchanges: options.HarExchanges,
+	}
+}
+
 type apiClient struct {
-	apiBaseURL string
+	apiBaseURL   string
+	schemaDrift  *SchemaDrift
+	rawResponses *RawResponses
+	harExchanges *HarExchanges
 }
 
 func (c *apiClient) GetToken(ctx context.Context, clientID, clientSecret string) (token string, err error) {
@@ -89,338 +131,213 @@ func (c *apiClient) GetToken(ctx context.Context, clientID, clientSecret string)
 }
 
 func (c *apiClient) GetPipelines(ctx context.Context, token string, pageNumber, pageSize int, filters map[string][]string) (response PipelinesListResponse, err error) {
-
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ApiClient::GetPipelines")
-	defer span.Finish()
-
-	getPipelinesURL := fmt.Sprintf("%v/api/pipelines?page[number]=%v&page[size]=%v", c.apiBaseURL, pageNumber, pageSize)
-	for k, v := range filters {
-		for _, vv := range v {
-			getPipelinesURL += "&" + k + "=" + vv
-		}
-	}
-
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %v", token),
-		"Content-Type":  "application/json",
-	}
-
-	responseBody, err := c.getRequest(getPipelinesURL, span, nil, headers)
-	if err != nil {
-		return
-	}
-
-	// unmarshal json body
-	err = json.Unmarshal(responseBody, &response)
-	if err != nil {
-		log.Error().Err(err).Str("body", string(responseBody)).Msgf("Failed unmarshalling get pipelines response from %v", getPipelinesURL)
-		return
-	}
-
-	return response, nil
+	err = c.getJSON(ctx, "GetPipelines", token, pagedPath("/api/pipelines", pageNumber, pageSize, filters), &response)
+	return
 }
 
 func (c *apiClient) GetPipeline(ctx context.Context, token string, pipelinePath string) (pipeline *contracts.Pipeline, err error) {
-
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ApiClient::GetPipeline")
-	defer span.Finish()
-
-	getPipelineURL := fmt.Sprintf("%v/api/pipelines/%v", c.apiBaseURL, pipelinePath)
-
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %v", token),
-		"Content-Type":  "application/json",
-	}
-
-	responseBody, err := c.getRequest(getPipelineURL, span, nil, headers)
-	if err != nil {
-		return
-	}
-
-	// unmarshal json body
-	err = json.Unmarshal(responseBody, &pipeline)
-	if err != nil {
-		log.Error().Err(err).Str("body", string(responseBody)).Msgf("Failed unmarshalling get pipeline response from %v", getPipelineURL)
-		return
-	}
-
-	return pipeline, nil
+	err = c.getJSON(ctx, "GetPipeline", token, fmt.Sprintf("/api/pipelines/%v", pipelinePath), &pipeline)
+	return
 }
 
 func (c *apiClient) GetPipelineBuilds(ctx context.Context, token string, pipelinePath string) (response PipelineBuildsListResponse, err error) {
-
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ApiClient::GetPipelineBuilds")
-	defer span.Finish()
-
-	getPipelineBuildsURL := fmt.Sprintf("%v/api/pipelines/%v/builds?page[number]=%v&page[size]=%v", c.apiBaseURL, pipelinePath, 1, 10)
-
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %v", token),
-		"Content-Type":  "application/json",
-	}
-
-	responseBody, err := c.getRequest(getPipelineBuildsURL, span, nil, headers)
-	if err != nil {
-		return
-	}
-
-	// unmarshal json body
-	err = json.Unmarshal(responseBody, &response)
-	if err != nil {
-		log.Error().Err(err).Str("body", string(responseBody)).Msgf("Failed unmarshalling get pipeline builds response from %v", getPipelineBuildsURL)
-		return
-	}
-
-	return response, nil
+	err = c.getJSON(ctx, "GetPipelineBuilds", token, fmt.Sprintf("/api/pipelines/%v/builds?page[number]=%v&page[size]=%v", pipelinePath, 1, 10), &response)
+	return
 }
 
 func (c *apiClient) GetPipelineBuild(ctx context.Context, token string, pipelineBuildPath string) (build *contracts.Build, err error) {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ApiClient::GetPipelineBuild")
-	defer span.Finish()
-
-	getPipelineBuildURL := fmt.Sprintf("%v%v", c.apiBaseURL, pipelineBuildPath)
-
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %v", token),
-		"Content-Type":  "application/json",
-	}
-
-	responseBody, err := c.getRequest(getPipelineBuildURL, span, nil, headers)
-	if err != nil {
-		return
-	}
-
-	// unmarshal json body
-	err = json.Unmarshal(responseBody, &build)
-	if err != nil {
-		log.Error().Err(err).Str("body", string(responseBody)).Msgf("Failed unmarshalling get pipeline build response from %v", getPipelineBuildURL)
-		return
-	}
-
-	return build, nil
+	err = c.getJSON(ctx, "GetPipelineBuild", token, pipelineBuildPath, &build)
+	return
 }
 
 func (c *apiClient) GetPipelineBuildLogs(ctx context.Context, token string, pipelineBuildPath string) (buildLogs PipelineBuildsLogsListResponse, err error) {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ApiClient::GetPipelineBuild")
-	defer span.Finish()
-
-	getPipelineBuildLogsURL := fmt.Sprintf("%v%v", c.apiBaseURL, pipelineBuildPath)
-
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %v", token),
-		"Content-Type":  "application/json",
-	}
-
-	responseBody, err := c.getRequest(getPipelineBuildLogsURL, span, nil, headers)
-	if err != nil {
-		return
-	}
-
-	// unmarshal json body
-	err = json.Unmarshal(responseBody, &buildLogs)
-	if err != nil {
-		log.Error().Err(err).Str("body", string(responseBody)).Msgf("Failed unmarshalling get pipeline build logs response from %v", getPipelineBuildLogsURL)
-		return
-	}
-
-	return buildLogs, nil
+	err = c.getJSON(ctx, "GetPipelineBuildLogs", token, pipelineBuildPath, &buildLogs)
+	return
 }
 
 func (c *apiClient) GetPipelineReleases(ctx context.Context, token string, pipelinePath string) (response PipelineReleasesListResponse, err error) {
+	err = c.getJSON(ctx, "GetPipelineReleases", token, fmt.Sprintf("/api/pipelines/%v/releases?page[number]=%v&page[size]=%v", pipelinePath, 1, 10), &response)
+	return
+}
 
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ApiClient::GetPipelineReleases")
-	defer span.Finish()
-
-	getPipelineReleasesURL := fmt.Sprintf("%v/api/pipelines/%v/releases?page[number]=%v&page[size]=%v", c.apiBaseURL, pipelinePath, 1, 10)
-
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %v", token),
-		"Content-Type":  "application/json",
-	}
+func (c *apiClient) GetPipelineRelease(ctx context.Context, token string, pipelineReleasePath string) (release *contracts.Release, err error) {
+	err = c.getJSON(ctx, "GetPipelineRelease", token, pipelineReleasePath, &release)
+	return
+}
 
-	responseBody, err := c.getRequest(getPipelineReleasesURL, span, nil, headers)
-	if err != nil {
-		return
-	}
+func (c *apiClient) GetPipelineReleaseLogs(ctx context.Context, token string, pipelineReleasePath string) (releaseLogs PipelineReleasesLogsListResponse, err error) {
+	err = c.getJSON(ctx, "GetPipelineReleaseLogs", token, pipelineReleasePath, &releaseLogs)
+	return
+}
 
-	// unmarshal json body
-	err = json.Unmarshal(responseBody, &response)
-	if err != nil {
-		log.Error().Err(err).Str("body", string(responseBody)).Msgf("Failed unmarshalling get pipeline releases response from %v", getPipelineReleasesURL)
-		return
-	}
+func (c *apiClient) GetPipelineBots(ctx context.Context, token string, pipelinePath string) (response PipelineBotsListResponse, err error) {
+	err = c.getJSON(ctx, "GetPipelineBots", token, fmt.Sprintf("/api/pipelines/%v/bots?page[number]=%v&page[size]=%v", pipelinePath, 1, 10), &response)
+	return
+}
 
-	return response, nil
+func (c *apiClient) GetPipelineBot(ctx context.Context, token string, pipelineBotPath string) (bot *contracts.Bot, err error) {
+	err = c.getJSON(ctx, "GetPipelineBot", token, pipelineBotPath, &bot)
+	return
 }
 
-func (c *apiClient) GetPipelineRelease(ctx context.Context, token string, pipelineReleasePath string) (release *contracts.Release, err error) {
+func (c *apiClient) GetPipelineBotLogs(ctx context.Context, token string, pipelineBotPath string) (botLogs PipelineBotsLogsListResponse, err error) {
+	err = c.getJSON(ctx, "GetPipelineBotLogs", token, pipelineBotPath, &botLogs)
+	return
+}
 
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ApiClient::GetPipelineRelease")
-	defer span.Finish()
+func (c *apiClient) GetPipelineBuildBranches(ctx context.Context, token string, pipelinePath string) (response PipelineBuildBranchesListResponse, err error) {
+	err = c.getJSON(ctx, "GetPipelineBuildBranches", token, fmt.Sprintf("/api/pipelines/%v/buildbranches", pipelinePath), &response)
+	return
+}
 
-	getPipelineReleaseURL := fmt.Sprintf("%v%v", c.apiBaseURL, pipelineReleasePath)
+func (c *apiClient) GetPipelineBotNames(ctx context.Context, token string, pipelinePath string) (response PipelineBotNamesListResponse, err error) {
+	err = c.getJSON(ctx, "GetPipelineBotNames", token, fmt.Sprintf("/api/pipelines/%v/botnames", pipelinePath), &response)
+	return
+}
 
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %v", token),
-		"Content-Type":  "application/json",
-	}
+func (c *apiClient) GetPipelineWarnings(ctx context.Context, token string, pipelinePath string) (response PipelineWarningsResponse, err error) {
+	err = c.getJSON(ctx, "GetPipelineWarnings", token, fmt.Sprintf("/api/pipelines/%v/warnings", pipelinePath), &response)
+	return
+}
 
-	responseBody, err := c.getRequest(getPipelineReleaseURL, span, nil, headers)
-	if err != nil {
-		return
-	}
+func (c *apiClient) GetPipelineStatsBuildsDurations(ctx context.Context, token string, pipelinePath string) (response PipelineDurationsResponse, err error) {
+	err = c.getJSON(ctx, "GetPipelineStatsBuildsDurations", token, fmt.Sprintf("/api/pipelines/%v/stats/buildsdurations", pipelinePath), &response)
+	return
+}
 
-	// unmarshal json body
-	err = json.Unmarshal(responseBody, &release)
-	if err != nil {
-		log.Error().Err(err).Str("body", string(responseBody)).Msgf("Failed unmarshalling get pipeline release response from %v", getPipelineReleaseURL)
-		return
-	}
+func (c *apiClient) GetPipelineStatsBuildsCPU(ctx context.Context, token string, pipelinePath string) (response PipelineMeasurementsResponse, err error) {
+	err = c.getJSON(ctx, "GetPipelineStatsBuildsCPU", token, fmt.Sprintf("/api/pipelines/%v/stats/buildscpu", pipelinePath), &response)
+	return
+}
 
-	return release, nil
+func (c *apiClient) GetPipelineStatsBuildsMemory(ctx context.Context, token string, pipelinePath string) (response PipelineMeasurementsResponse, err error) {
+	err = c.getJSON(ctx, "GetPipelineStatsBuildsMemory", token, fmt.Sprintf("/api/pipelines/%v/stats/buildsmemory", pipelinePath), &response)
+	return
 }
 
-func (c *apiClient) GetPipelineReleaseLogs(ctx context.Context, token string, pipelineReleasePath string) (releaseLogs PipelineReleasesLogsListResponse, err error) {
+func (c *apiClient) GetPipelineStatsReleasesDurations(ctx context.Context, token string, pipelinePath string) (response PipelineDurationsResponse, err error) {
+	err = c.getJSON(ctx, "GetPipelineStatsReleasesDurations", token, fmt.Sprintf("/api/pipelines/%v/stats/releasesdurations", pipelinePath), &response)
+	return
+}
 
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ApiClient::GetPipelineRelease")
-	defer span.Finish()
+func (c *apiClient) GetPipelineStatsReleasesCPU(ctx context.Context, token string, pipelinePath string) (response PipelineMeasurementsResponse, err error) {
+	err = c.getJSON(ctx, "GetPipelineStatsReleasesCPU", token, fmt.Sprintf("/api/pipelines/%v/stats/releasescpu", pipelinePath), &response)
+	return
+}
 
-	getPipelineReleaseLogsURL := fmt.Sprintf("%v%v", c.apiBaseURL, pipelineReleasePath)
+func (c *apiClient) GetPipelineStatsReleasesMemory(ctx context.Context, token string, pipelinePath string) (response PipelineMeasurementsResponse, err error) {
+	err = c.getJSON(ctx, "GetPipelineStatsReleasesMemory", token, fmt.Sprintf("/api/pipelines/%v/stats/releasesmemory", pipelinePath), &response)
+	return
+}
 
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %v", token),
-		"Content-Type":  "application/json",
-	}
+func (c *apiClient) GetCatalogEntities(ctx context.Context, token string, pageNumber, pageSize int, filters map[string][]string) (response CatalogEntitiesListResponse, err error) {
+	err = c.getJSON(ctx, "GetCatalogEntities", token, pagedPath("/api/catalog/entities", pageNumber, pageSize, filters), &response)
+	return
+}
 
-	responseBody, err := c.getRequest(getPipelineReleaseLogsURL, span, nil, headers)
-	if err != nil {
-		return
-	}
+func (c *apiClient) GetCatalogFilters(ctx context.Context, token string) (filters []string, err error) {
+	err = c.getJSON(ctx, "GetCatalogFilters", token, "/api/catalog/filters", &filters)
+	return
+}
 
-	// unmarshal json body
-	err = json.Unmarshal(responseBody, &releaseLogs)
-	if err != nil {
-		log.Error().Err(err).Str("body", string(responseBody)).Msgf("Failed unmarshalling get pipeline release logs response from %v", getPipelineReleaseLogsURL)
-		return
-	}
+func (c *apiClient) GetLabels(ctx context.Context, token string, pageNumber, pageSize int) (response LabelsListResponse, err error) {
+	err = c.getJSON(ctx, "GetLabels", token, pagedPath("/api/labels", pageNumber, pageSize, nil), &response)
+	return
+}
 
-	return releaseLogs, nil
+func (c *apiClient) GetFrequentLabels(ctx context.Context, token string, pageNumber, pageSize int) (response FrequentLabelsListResponse, err error) {
+	err = c.getJSON(ctx, "GetFrequentLabels", token, pagedPath("/api/labels/frequent", pageNumber, pageSize, nil), &response)
+	return
 }
 
-func (c *apiClient) GetPipelineBots(ctx context.Context, token string, pipelinePath string) (response PipelineBotsListResponse, err error) {
+func (c *apiClient) GetStatsMostBuilds(ctx context.Context, token string, pageNumber, pageSize int, filters map[string][]string) (response PipelineCountsListResponse, err error) {
+	err = c.getJSON(ctx, "GetStatsMostBuilds", token, pagedPath("/api/stats/mostbuilds", pageNumber, pageSize, filters), &response)
+	return
+}
 
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ApiClient::GetPipelineBots")
-	defer span.Finish()
+func (c *apiClient) GetStatsMostReleases(ctx context.Context, token string, pageNumber, pageSize int, filters map[string][]string) (response PipelineCountsListResponse, err error) {
+	err = c.getJSON(ctx, "GetStatsMostReleases", token, pagedPath("/api/stats/mostreleases", pageNumber, pageSize, filters), &response)
+	return
+}
 
-	getPipelineBotsURL := fmt.Sprintf("%v/api/pipelines/%v/bots?page[number]=%v&page[size]=%v", c.apiBaseURL, pipelinePath, 1, 10)
+func (c *apiClient) GetReleaseTargets(ctx context.Context, token string, pageNumber, pageSize int) (response ReleaseTargetsListResponse, err error) {
+	err = c.getJSON(ctx, "GetReleaseTargets", token, pagedPath("/api/releasetargets", pageNumber, pageSize, nil), &response)
+	return
+}
 
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %v", token),
-		"Content-Type":  "application/json",
-	}
+func (c *apiClient) GetOrganizations(ctx context.Context, token string, pageNumber, pageSize int) (response OrganizationsListResponse, err error) {
+	err = c.getJSON(ctx, "GetOrganizations", token, pagedPath("/api/organizations", pageNumber, pageSize, nil), &response)
+	return
+}
 
-	responseBody, err := c.getRequest(getPipelineBotsURL, span, nil, headers)
-	if err != nil {
-		return
-	}
+func (c *apiClient) GetBytesResponse(ctx context.Context, token string, path string) (bytes []byte, err error) {
+	return c.getBytes(ctx, "GetBytesResponse", token, path)
+}
 
-	// unmarshal json body
-	err = json.Unmarshal(responseBody, &response)
-	if err != nil {
-		log.Error().Err(err).Str("body", string(responseBody)).Msgf("Failed unmarshalling get pipeline bots response from %v", getPipelineBotsURL)
-		return
+// pagedPath appends the page and filter parameters to path
+func pagedPath(path string, pageNumber, pageSize int, filters map[string][]string) string {
+	path += fmt.Sprintf("?page[number]=%v&page[size]=%v", pageNumber, pageSize)
+	for k, v := range filters {
+		for _, vv := range v {
+			path += "&" + k + "=" + vv
+		}
 	}
 
-	return response, nil
+	return path
 }
 
-func (c *apiClient) GetPipelineBot(ctx context.Context, token string, pipelineBotPath string) (bot *contracts.Bot, err error) {
-
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ApiClient::GetPipelineBot")
-	defer span.Finish()
+// getJSON requests path relative to the api base url within a span named after the calling method and unmarshals the json response into target
+func (c *apiClient) getJSON(ctx context.Context, method, token, path string, target interface{}) (err error) {
 
-	getPipelineBotURL := fmt.Sprintf("%v%v", c.apiBaseURL, pipelineBotPath)
-
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %v", token),
-		"Content-Type":  "application/json",
-	}
-
-	responseBody, err := c.getRequest(getPipelineBotURL, span, nil, headers)
+	responseBody, err := c.getBytes(ctx, method, token, path)
 	if err != nil {
 		return
 	}
 
 	// unmarshal json body
-	err = json.Unmarshal(responseBody, &bot)
+	err = json.Unmarshal(responseBody, target)
 	if err != nil {
-		log.Error().Err(err).Str("body", string(responseBody)).Msgf("Failed unmarshalling get pipeline bot response from %v", getPipelineBotURL)
+		log.Error().Err(err).Str("body", string(responseBody)).Msgf("Failed unmarshalling %v response from %v%v", method, c.apiBaseURL, path)
 		return
 	}
 
-	return bot, nil
-}
-
-func (c *apiClient) GetPipelineBotLogs(ctx context.Context, token string, pipelineBotPath string) (botLogs PipelineBotsLogsListResponse, err error) {
-
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ApiClient::GetPipelineBot")
-	defer span.Finish()
-
-	getPipelineBotLogsURL := fmt.Sprintf("%v%v", c.apiBaseURL, pipelineBotPath)
-
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %v", token),
-		"Content-Type":  "application/json",
-	}
-
-	responseBody, err := c.getRequest(getPipelineBotLogsURL, span, nil, headers)
-	if err != nil {
-		return
+	if c.schemaDrift != nil {
+		err = c.schemaDrift.Check(method, path, responseBody, target)
+		if err != nil {
+			return
+		}
 	}
 
-	// unmarshal json body
-	err = json.Unmarshal(responseBody, &botLogs)
-	if err != nil {
-		log.Error().Err(err).Str("body", string(responseBody)).Msgf("Failed unmarshalling get pipeline bot logs response from %v", getPipelineBotLogsURL)
-		return
+	if c.rawResponses != nil {
+		c.rawResponses.Add(path, responseBody)
 	}
 
-	return botLogs, nil
+	return nil
 }
 
-func (c *apiClient) GetBytesResponse(ctx context.Context, token string, path string) (bytes []byte, err error) {
+// getBytes requests path relative to the api base url within a span named after the calling method and returns the raw response
+func (c *apiClient) getBytes(ctx context.Context, method, token, path string) (bytes []byte, err error) {
 
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ApiClient::GetBytesResponse")
+	span, _ := opentracing.StartSpanFromContext(ctx, "ApiClient::"+method)
 	defer span.Finish()
 
-	url := fmt.Sprintf("%v%v", c.apiBaseURL, path)
+	return c.getRequest(c.apiBaseURL+path, span, nil, c.authorizationHeaders(token))
+}
 
-	headers := map[string]string{
+func (c *apiClient) authorizationHeaders(token string) map[string]string {
+	return map[string]string{
 		"Authorization": fmt.Sprintf("Bearer %v", token),
 		"Content-Type":  "application/json",
 	}
-
-	bytes, err = c.getRequest(url, span, nil, headers)
-	if err != nil {
-		return
-	}
-
-	return bytes, nil
 }
 
 func (c *apiClient) GetSSEResponse(ctx context.Context, token string, path string, maxNumberOfEvents int) (bytes []byte, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "ApiClient::GetSSEResponse")
 	defer span.Finish()
 
-	url := fmt.Sprintf("%v%v", c.apiBaseURL, path)
-
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %v", token),
-		"Content-Type":  "application/json",
-	}
-
-	client := sse.NewClient(url)
-	client.Headers = headers
+	client := sse.NewClient(c.apiBaseURL + path)
+	client.Headers = c.authorizationHeaders(token)
+	client.Connection.Transport = c.transport(client.Connection.Transport)
 
 	events := make(chan *sse.Event)
 	err = client.SubscribeChanRaw(events)
@@ -450,6 +367,19 @@ func (c *apiClient) GetSSEResponse(ctx context.Context, token string, path strin
 	return bytes, nil
 }
 
+// transport returns the round tripper for requests to the api, keeping their exchanges if set; a nil base stands for the default transport
+func (c *apiClient) transport(base http.RoundTripper) http.RoundTripper {
+
+	if c.harExchanges == nil {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &harExchangeTransport{RoundTripper: base, apiBaseURL: c.apiBaseURL, exchanges: c.harExchanges}
+}
+
 func (c *apiClient) getRequest(uri string, span opentracing.Span, requestBody io.Reader, headers map[string]string, allowedStatusCodes ...int) (responseBody []byte, err error) {
 	return c.makeRequest("GET", uri, span, requestBody, headers, allowedStatusCodes...)
 }
@@ -469,7 +399,7 @@ func (c *apiClient) deleteRequest(uri string, span opentracing.Span, requestBody
 func (c *apiClient) makeRequest(method, uri string, span opentracing.Span, requestBody io.Reader, headers map[string]string, allowedStatusCodes ...int) (responseBody []byte, err error) {
 
 	// create client, in order to add headers
-	client := pester.NewExtendedClient(&http.Client{Transport: &nethttp.Transport{}})
+	client := pester.NewExtendedClient(&http.Client{Transport: &nethttp.Transport{RoundTripper: c.transport(nil)}})
 	client.MaxRetries = 3
 	client.Backoff = pester.ExponentialJitterBackoff
 	client.KeepLog = true
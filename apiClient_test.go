@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -11,14 +14,8 @@ import (
 func TestGetToken(t *testing.T) {
 	t.Run("ReturnsToken", func(t *testing.T) {
 
-		if testing.Short() {
-			t.Skip("skipping test in short mode.")
-		}
-
 		ctx := context.Background()
-		getBaseURL := os.Getenv("API_BASE_URL")
-		clientID := os.Getenv("CLIENT_ID")
-		clientSecret := os.Getenv("CLIENT_SECRET")
+		getBaseURL, clientID, clientSecret := testAPI(t)
 		client := NewApiClient(getBaseURL)
 
 		// act
@@ -32,14 +29,8 @@ func TestGetToken(t *testing.T) {
 func TestGetPipelines(t *testing.T) {
 	t.Run("ReturnsPipelines", func(t *testing.T) {
 
-		if testing.Short() {
-			t.Skip("skipping test in short mode.")
-		}
-
 		ctx := context.Background()
-		getBaseURL := os.Getenv("API_BASE_URL")
-		clientID := os.Getenv("CLIENT_ID")
-		clientSecret := os.Getenv("CLIENT_SECRET")
+		getBaseURL, clientID, clientSecret := testAPI(t)
 		client := NewApiClient(getBaseURL)
 		token, err := client.GetToken(ctx, clientID, clientSecret)
 		assert.Nil(t, err)
@@ -57,3 +48,108 @@ func TestGetPipelines(t *testing.T) {
 		assert.True(t, response.Pagination.TotalItems > 0)
 	})
 }
+
+func TestGetPipelineBuild(t *testing.T) {
+	t.Run("ReturnsBuildFromPath", func(t *testing.T) {
+
+		var requestedPath, authorizationHeader string
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestedPath = r.URL.Path
+			authorizationHeader = r.Header.Get("Authorization")
+			w.Write([]byte(`{"id":"5","buildVersion":"1.0.5","buildStatus":"succeeded"}`))
+		}))
+		defer api.Close()
+
+		ctx := context.Background()
+		client := NewApiClient(api.URL)
+
+		// act
+		build, err := client.GetPipelineBuild(ctx, "abc", "/api/pipelines/github.com/estafette/estafette-ci-api/builds/5")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "/api/pipelines/github.com/estafette/estafette-ci-api/builds/5", requestedPath)
+		assert.Equal(t, "Bearer abc", authorizationHeader)
+		assert.Equal(t, "1.0.5", build.BuildVersion)
+	})
+
+	t.Run("ReturnsErrorForInvalidJSON", func(t *testing.T) {
+
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"id":`))
+		}))
+		defer api.Close()
+
+		ctx := context.Background()
+		client := NewApiClient(api.URL)
+
+		// act
+		_, err := client.GetPipelineBuild(ctx, "abc", "/api/pipelines/github.com/estafette/estafette-ci-api/builds/5")
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGetPipelineWarnings(t *testing.T) {
+	t.Run("ReturnsWarningsFromPipelinePath", func(t *testing.T) {
+
+		var requestedPath string
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestedPath = r.URL.Path
+			w.Write([]byte(`{"warnings":[{"status":"warning","message":"This pipeline has no recent builds"}]}`))
+		}))
+		defer api.Close()
+
+		ctx := context.Background()
+		client := NewApiClient(api.URL)
+
+		// act
+		response, err := client.GetPipelineWarnings(ctx, "abc", "github.com/estafette/estafette-ci-api")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "/api/pipelines/github.com/estafette/estafette-ci-api/warnings", requestedPath)
+		if assert.Equal(t, 1, len(response.Warnings)) {
+			assert.Equal(t, "warning", response.Warnings[0].Status)
+		}
+	})
+}
+
+func TestGetPipelineStatsBuildsDurations(t *testing.T) {
+	t.Run("ReturnsDurationsFromPipelinePath", func(t *testing.T) {
+
+		var requestedPath string
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestedPath = r.URL.Path
+			w.Write([]byte(`{"durations":[{"insertedAt":"2020-01-02T10:00:00Z","duration":90000000000}]}`))
+		}))
+		defer api.Close()
+
+		ctx := context.Background()
+		client := NewApiClient(api.URL)
+
+		// act
+		response, err := client.GetPipelineStatsBuildsDurations(ctx, "abc", "github.com/estafette/estafette-ci-api")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "/api/pipelines/github.com/estafette/estafette-ci-api/stats/buildsdurations", requestedPath)
+		if assert.Equal(t, 1, len(response.Durations)) {
+			assert.Equal(t, 90*time.Second, response.Durations[0].Duration)
+		}
+	})
+}
+
+// testAPI returns the api configured through API_BASE_URL, CLIENT_ID and CLIENT_SECRET, or a fake api if none is configured
+func testAPI(t *testing.T) (apiBaseURL, clientID, clientSecret string) {
+
+	apiBaseURL = os.Getenv("API_BASE_URL")
+	if apiBaseURL == "" {
+		api := newFakeAPIServer(newFakeDataset(2, 3))
+		t.Cleanup(api.Close)
+		return api.URL, "fake-client", "fake-secret"
+	}
+
+	if testing.Short() {
+		t.Skip("skipping test against live api in short mode.")
+	}
+
+	return apiBaseURL, os.Getenv("CLIENT_ID"), os.Getenv("CLIENT_SECRET")
+}
@@ -0,0 +1,999 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultAugmentBuildsPerPipeline = 20
+	defaultAugmentReleasesPerTarget = 5
+	defaultAugmentBotsPerPipeline   = 5
+)
+
+// versionRegex splits a version into its major.minor prefix, its patch number and whatever follows, like a branch suffix
+var versionRegex = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)(.*)$`)
+
+// AugmenterOptions configures how much history an Augmenter adds to every pipeline of an export
+type AugmenterOptions struct {
+	// Seed makes the augmentation reproducible, the same seed and export give the same augmented export
+	Seed int64
+
+	// BuildsPerPipeline is the number of builds to add to every pipeline, defaults to 20
+	BuildsPerPipeline int
+
+	// ReleasesPerTarget is the number of releases to add for every release target of a pipeline, defaults to 5
+	ReleasesPerTarget int
+
+	// BotsPerPipeline is the number of bot runs to add to every pipeline, defaults to 5
+	BotsPerPipeline int
+
+	// PageSize splits augmented lists into pages of this size next to the full list, 0 disables paging
+	PageSize int
+}
+
+// Augmenter adds builds, releases and bots cloned from the exported ones to every pipeline of an export and stores the
+// augmented export into a sink; clones get new ids, versions, commits and timestamps before the oldest exported run, so
+// the pipelines themselves, their stages and their active releases stay as exported
+type Augmenter struct {
+	directory string
+	sink      FixtureSink
+	options   AugmenterOptions
+
+	nextID   int
+	replaced map[string]bool
+	builds   []*contracts.Build
+	releases []*contracts.Release
+
+	// the number of cloned builds and releases by pipeline path, added to the counts of the insights
+	clonedBuildCounts   map[string]int
+	clonedReleaseCounts map[string]int
+}
+
+// NewAugmenter returns an Augmenter reading the export in directory and storing into sink
+func NewAugmenter(directory string, sink FixtureSink, options AugmenterOptions) *Augmenter {
+
+	if options.BuildsPerPipeline <= 0 {
+		options.BuildsPerPipeline = defaultAugmentBuildsPerPipeline
+	}
+	if options.ReleasesPerTarget <= 0 {
+		options.ReleasesPerTarget = defaultAugmentReleasesPerTarget
+	}
+	if options.BotsPerPipeline <= 0 {
+		options.BotsPerPipeline = defaultAugmentBotsPerPipeline
+	}
+
+	return &Augmenter{
+		directory: directory,
+		sink:      sink,
+		options:   options,
+		replaced:  map[string]bool{},
+
+		clonedBuildCounts:   map[string]int{},
+		clonedReleaseCounts: map[string]int{},
+	}
+}
+
+// Run augments all exported pipelines, stores the lists across them, copies every other file of the export and finalizes the sink
+func (a *Augmenter) Run(ctx context.Context) (err error) {
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Augment")
+	defer span.Finish()
+
+	pipelines, err := readExportedPipelines(a.directory)
+	if err != nil {
+		return
+	}
+	if len(pipelines) == 0 {
+		return fmt.Errorf("Directory %v doesn't hold any exported pipelines", a.directory)
+	}
+
+	// clones get ids above the exported ones, so they never collide
+	maxID, err := maxExportedID(a.directory)
+	if err != nil {
+		return
+	}
+	a.nextID = maxID + 1
+
+	for i, path := range sortedKeys(pipelines) {
+		err = a.augmentPipeline(ctx, i, pipelines[path])
+		if err != nil {
+			return
+		}
+	}
+
+	builds := aggregateBuilds(a.builds)
+	err = a.saveList("/api/builds", builds, builds.Items)
+	if err != nil {
+		return
+	}
+
+	releases := aggregateReleases(a.releases)
+	err = a.saveList("/api/releases", releases, releases.Items)
+	if err != nil {
+		return
+	}
+
+	err = a.augmentPipelineCounts("/api/stats/mostbuilds", a.clonedBuildCounts)
+	if err != nil {
+		return
+	}
+	err = a.augmentPipelineCounts("/api/stats/mostreleases", a.clonedReleaseCounts)
+	if err != nil {
+		return
+	}
+	err = a.recountReleaseTargets(pipelines)
+	if err != nil {
+		return
+	}
+
+	err = a.copyExport()
+	if err != nil {
+		return
+	}
+
+	return a.sink.Finalize()
+}
+
+// augmentPipeline stores the builds, releases and bots lists of pipeline number i extended with clones, and the clones themselves
+func (a *Augmenter) augmentPipeline(ctx context.Context, i int, pipeline *contracts.Pipeline) (err error) {
+
+	span, _ := opentracing.StartSpanFromContext(ctx, "AugmentPipeline")
+	defer span.Finish()
+	pipelinePath := pipeline.GetFullRepoPath()
+	span.SetTag("pipeline", pipelinePath)
+
+	// every pipeline gets its own source of randomness, like the generator does
+	random := rand.New(rand.NewSource(a.options.Seed*1000003 + int64(i)))
+	url := "/api/pipelines/" + pipelinePath
+
+	var builds PipelineBuildsListResponse
+	err = readExportedList(a.directory, pipelinePath, "builds", &builds)
+	if err != nil {
+		return
+	}
+	var releases PipelineReleasesListResponse
+	err = readExportedList(a.directory, pipelinePath, "releases", &releases)
+	if err != nil {
+		return
+	}
+	var bots PipelineBotsListResponse
+	err = readExportedList(a.directory, pipelinePath, "bots", &bots)
+	if err != nil {
+		return
+	}
+
+	clonedBuilds, err := a.cloneBuilds(random, url, pipeline, builds.Items)
+	if err != nil {
+		return
+	}
+	clonedReleases, err := a.cloneReleases(random, url, pipeline, releases.Items, clonedBuilds)
+	if err != nil {
+		return
+	}
+	clonedBots, err := a.cloneBots(random, url, bots.Items)
+	if err != nil {
+		return
+	}
+
+	// clones are older than every exported run, so they go at the end of the lists sorted most recent first
+	builds.Items = append(builds.Items, clonedBuilds...)
+	builds.Pagination = singlePage(len(builds.Items))
+	err = a.saveList(url+"/builds", builds, builds.Items)
+	if err != nil {
+		return
+	}
+
+	releases.Items = append(releases.Items, aggregateReleases(clonedReleases).Items...)
+	releases.Pagination = singlePage(len(releases.Items))
+	err = a.saveList(url+"/releases", releases, releases.Items)
+	if err != nil {
+		return
+	}
+
+	bots.Items = append(bots.Items, clonedBots...)
+	bots.Pagination = singlePage(len(bots.Items))
+	err = a.saveList(url+"/bots", bots, bots.Items)
+	if err != nil {
+		return
+	}
+
+	err = a.augmentNameCounts(url+"/buildbranches", len(clonedBuilds), func(i int) string { return clonedBuilds[i].RepoBranch })
+	if err != nil {
+		return
+	}
+	err = a.augmentNameCounts(url+"/botnames", len(clonedBots), func(i int) string { return clonedBots[i].Name })
+	if err != nil {
+		return
+	}
+
+	err = a.augmentStats(random, url, clonedBuilds, clonedReleases)
+	if err != nil {
+		return
+	}
+
+	a.builds = append(a.builds, builds.Items...)
+	a.releases = append(a.releases, releases.Items...)
+	a.clonedBuildCounts[pipelinePath] = len(clonedBuilds)
+	a.clonedReleaseCounts[pipelinePath] = len(clonedReleases)
+
+	log.Info().Msgf("Augmented pipeline %v with %v builds, %v releases and %v bots", pipelinePath, len(clonedBuilds), len(clonedReleases), len(clonedBots))
+
+	return nil
+}
+
+// cloneBuilds stores clones of finished builds before the oldest of builds and returns their list items, most recent first
+func (a *Augmenter) cloneBuilds(random *rand.Rand, pipelineURL string, pipeline *contracts.Pipeline, builds []*contracts.Build) (clones []*contracts.Build, err error) {
+
+	sources := []*contracts.Build{}
+	times := []time.Time{}
+	commits := []contracts.GitCommit{}
+	var oldest *contracts.Build
+	for _, b := range builds {
+		if isFinished(b.BuildStatus) {
+			sources = append(sources, b)
+		}
+		times = append(times, b.InsertedAt)
+		commits = append(commits, b.Commits...)
+		// versions count down from the oldest build on the main branch, branch builds take the same counter
+		if oldest == nil || b.RepoBranch == pipeline.RepoBranch && (oldest.RepoBranch != pipeline.RepoBranch || b.InsertedAt.Before(oldest.InsertedAt)) {
+			oldest = b
+		}
+	}
+	if len(sources) == 0 {
+		return
+	}
+
+	interval := runInterval(times, 4*time.Hour)
+	start := earliest(times)
+	for i := 0; i < a.options.BuildsPerPipeline; i++ {
+		source := sources[random.Intn(len(sources))]
+		insertedAt := start.Add(-time.Duration(i+1)*interval + jitter(random, interval))
+
+		clone := runClone{
+			id:       a.newID(),
+			shift:    insertedAt.Sub(source.InsertedAt),
+			version:  augmentedVersion(oldest.BuildVersion, source.BuildVersion, i+1),
+			revision: randomRevision(random),
+			commits:  cloneCommits(random, commits),
+		}
+		clone.replacer = newCloneReplacer(source.BuildVersion, clone.version, source.RepoRevision, clone.revision)
+
+		build, err := a.cloneBuild(clone, source)
+		if err != nil {
+			return nil, err
+		}
+		clones = append(clones, build)
+
+		err = a.cloneBuildFiles(pipelineURL, clone, source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return clones, nil
+}
+
+// cloneBuild returns a clone of the build item, as found in a list or stored on its own
+func (a *Augmenter) cloneBuild(clone runClone, item *contracts.Build) (*contracts.Build, error) {
+
+	var build contracts.Build
+	err := cloneJSON(item, &build)
+	if err != nil {
+		return nil, err
+	}
+
+	build.ID = clone.id
+	build.BuildVersion = clone.version
+	build.RepoRevision = clone.revision
+	build.Commits = clone.commits
+	build.InsertedAt = build.InsertedAt.Add(clone.shift)
+	build.StartedAt = shiftTime(build.StartedAt, clone.shift)
+	build.UpdatedAt = build.UpdatedAt.Add(clone.shift)
+
+	return &build, nil
+}
+
+// cloneBuildFiles stores the clone of source along with its warnings and logs
+func (a *Augmenter) cloneBuildFiles(pipelineURL string, clone runClone, source *contracts.Build) (err error) {
+
+	sourceURL := fmt.Sprintf("%v/builds/%v", pipelineURL, source.ID)
+	url := fmt.Sprintf("%v/builds/%v", pipelineURL, clone.id)
+
+	// the stored build holds more than the list item, like the manifest with its stages
+	detail := source
+	var stored contracts.Build
+	if ok, err := a.readFixture(sourceURL, &stored); err != nil {
+		return err
+	} else if ok {
+		detail = &stored
+	}
+	build, err := a.cloneBuild(clone, detail)
+	if err != nil {
+		return
+	}
+	err = saveObject(a.sink, url, build)
+	if err != nil {
+		return
+	}
+
+	err = a.copyFixture(sourceURL+"/warnings", url+"/warnings")
+	if err != nil {
+		return
+	}
+
+	var logs PipelineBuildsLogsListResponse
+	ok, err := a.readFixture(sourceURL+"/alllogs", &logs)
+	if err != nil || !ok {
+		return
+	}
+	for _, l := range logs.Items {
+		sourceLogID := l.ID
+		l.ID = a.newID()
+		l.BuildID = clone.id
+		l.RepoRevision = build.RepoRevision
+		l.InsertedAt = l.InsertedAt.Add(clone.shift)
+		clone.steps(l.Steps)
+
+		var stored contracts.BuildLog
+		if ok, err := a.readFixture(fmt.Sprintf("%v/logsbyid/%v", sourceURL, sourceLogID), &stored); err != nil {
+			return err
+		} else if ok {
+			stored.ID, stored.BuildID, stored.RepoRevision, stored.InsertedAt = l.ID, l.BuildID, l.RepoRevision, stored.InsertedAt.Add(clone.shift)
+			clone.steps(stored.Steps)
+			err = saveObject(a.sink, fmt.Sprintf("%v/logsbyid/%v", url, l.ID), stored)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return saveObject(a.sink, url+"/alllogs", logs)
+}
+
+// cloneReleases stores clones of finished releases per release target of pipeline, releasing the succeeded cloned builds on the
+// main branch, and returns their list items
+func (a *Augmenter) cloneReleases(random *rand.Rand, pipelineURL string, pipeline *contracts.Pipeline, releases []*contracts.Release, clonedBuilds []*contracts.Build) (clones []*contracts.Release, err error) {
+
+	releasable := []*contracts.Build{}
+	for _, b := range clonedBuilds {
+		if b.BuildStatus == contracts.StatusSucceeded && b.RepoBranch == pipeline.RepoBranch {
+			releasable = append(releasable, b)
+		}
+	}
+
+	for _, target := range pipeline.ReleaseTargets {
+		sources := []*contracts.Release{}
+		for _, r := range releases {
+			if r.Name == target.Name && isFinished(r.ReleaseStatus) && r.InsertedAt != nil {
+				sources = append(sources, r)
+			}
+		}
+		if len(sources) == 0 {
+			continue
+		}
+
+		for i := 0; i < a.options.ReleasesPerTarget && i < len(releasable); i++ {
+			source := sources[random.Intn(len(sources))]
+			build := releasable[i]
+			insertedAt := build.UpdatedAt.Add(time.Duration(5+random.Intn(120)) * time.Minute)
+
+			clone := runClone{
+				id:      a.newID(),
+				shift:   insertedAt.Sub(*source.InsertedAt),
+				version: build.BuildVersion,
+			}
+			clone.replacer = newCloneReplacer(source.ReleaseVersion, clone.version)
+
+			release, err := a.cloneRelease(clone, source)
+			if err != nil {
+				return nil, err
+			}
+			clones = append(clones, release)
+
+			err = a.cloneReleaseFiles(pipelineURL, clone, source)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return clones, nil
+}
+
+// cloneRelease returns a clone of the release item, as found in a list or stored on its own
+func (a *Augmenter) cloneRelease(clone runClone, item *contracts.Release) (*contracts.Release, error) {
+
+	var release contracts.Release
+	err := cloneJSON(item, &release)
+	if err != nil {
+		return nil, err
+	}
+
+	release.ID = clone.id
+	release.ReleaseVersion = clone.version
+	release.InsertedAt = shiftTime(release.InsertedAt, clone.shift)
+	release.StartedAt = shiftTime(release.StartedAt, clone.shift)
+	release.UpdatedAt = shiftTime(release.UpdatedAt, clone.shift)
+
+	return &release, nil
+}
+
+// cloneReleaseFiles stores the clone of source along with its logs
+func (a *Augmenter) cloneReleaseFiles(pipelineURL string, clone runClone, source *contracts.Release) (err error) {
+
+	sourceURL := fmt.Sprintf("%v/releases/%v", pipelineURL, source.ID)
+	url := fmt.Sprintf("%v/releases/%v", pipelineURL, clone.id)
+
+	detail := source
+	var stored contracts.Release
+	if ok, err := a.readFixture(sourceURL, &stored); err != nil {
+		return err
+	} else if ok {
+		detail = &stored
+	}
+	release, err := a.cloneRelease(clone, detail)
+	if err != nil {
+		return
+	}
+	err = saveObject(a.sink, url, release)
+	if err != nil {
+		return
+	}
+
+	var logs PipelineReleasesLogsListResponse
+	ok, err := a.readFixture(sourceURL+"/alllogs", &logs)
+	if err != nil || !ok {
+		return
+	}
+	for _, l := range logs.Items {
+		sourceLogID := l.ID
+		l.ID = a.newID()
+		l.ReleaseID = clone.id
+		l.InsertedAt = l.InsertedAt.Add(clone.shift)
+		clone.steps(l.Steps)
+
+		var stored contracts.ReleaseLog
+		if ok, err := a.readFixture(fmt.Sprintf("%v/logsbyid/%v", sourceURL, sourceLogID), &stored); err != nil {
+			return err
+		} else if ok {
+			stored.ID, stored.ReleaseID, stored.InsertedAt = l.ID, l.ReleaseID, stored.InsertedAt.Add(clone.shift)
+			clone.steps(stored.Steps)
+			err = saveObject(a.sink, fmt.Sprintf("%v/logsbyid/%v", url, l.ID), stored)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return saveObject(a.sink, url+"/alllogs", logs)
+}
+
+// cloneBots stores clones of finished bot runs before the oldest of bots and returns their list items, most recent first
+func (a *Augmenter) cloneBots(random *rand.Rand, pipelineURL string, bots []*contracts.Bot) (clones []*contracts.Bot, err error) {
+
+	sources := []*contracts.Bot{}
+	times := []time.Time{}
+	for _, b := range bots {
+		if isFinished(b.BotStatus) && b.InsertedAt != nil {
+			sources = append(sources, b)
+			times = append(times, *b.InsertedAt)
+		}
+	}
+	if len(sources) == 0 {
+		return
+	}
+
+	interval := runInterval(times, 24*time.Hour)
+	start := earliest(times)
+	for i := 0; i < a.options.BotsPerPipeline; i++ {
+		source := sources[random.Intn(len(sources))]
+		insertedAt := start.Add(-time.Duration(i+1)*interval + jitter(random, interval))
+		clone := runClone{
+			id:    a.newID(),
+			shift: insertedAt.Sub(*source.InsertedAt),
+		}
+
+		bot, err := a.cloneBot(clone, source)
+		if err != nil {
+			return nil, err
+		}
+		clones = append(clones, bot)
+
+		err = a.cloneBotFiles(pipelineURL, clone, source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return clones, nil
+}
+
+// cloneBot returns a clone of the bot item, as found in a list or stored on its own
+func (a *Augmenter) cloneBot(clone runClone, item *contracts.Bot) (*contracts.Bot, error) {
+
+	var bot contracts.Bot
+	err := cloneJSON(item, &bot)
+	if err != nil {
+		return nil, err
+	}
+
+	bot.ID = clone.id
+	bot.InsertedAt = shiftTime(bot.InsertedAt, clone.shift)
+	bot.StartedAt = shiftTime(bot.StartedAt, clone.shift)
+	bot.UpdatedAt = shiftTime(bot.UpdatedAt, clone.shift)
+
+	return &bot, nil
+}
+
+// cloneBotFiles stores the clone of source along with its logs
+func (a *Augmenter) cloneBotFiles(pipelineURL string, clone runClone, source *contracts.Bot) (err error) {
+
+	sourceURL := fmt.Sprintf("%v/bots/%v", pipelineURL, source.ID)
+	url := fmt.Sprintf("%v/bots/%v", pipelineURL, clone.id)
+
+	detail := source
+	var stored contracts.Bot
+	if ok, err := a.readFixture(sourceURL, &stored); err != nil {
+		return err
+	} else if ok {
+		detail = &stored
+	}
+	bot, err := a.cloneBot(clone, detail)
+	if err != nil {
+		return
+	}
+	err = saveObject(a.sink, url, bot)
+	if err != nil {
+		return
+	}
+
+	var logs PipelineBotsLogsListResponse
+	ok, err := a.readFixture(sourceURL+"/alllogs", &logs)
+	if err != nil || !ok {
+		return
+	}
+	for _, l := range logs.Items {
+		sourceLogID := l.ID
+		l.ID = a.newID()
+		l.BotID = clone.id
+		l.InsertedAt = l.InsertedAt.Add(clone.shift)
+		clone.steps(l.Steps)
+
+		var stored contracts.BotLog
+		if ok, err := a.readFixture(fmt.Sprintf("%v/logsbyid/%v", sourceURL, sourceLogID), &stored); err != nil {
+			return err
+		} else if ok {
+			stored.ID, stored.BotID, stored.InsertedAt = l.ID, l.BotID, stored.InsertedAt.Add(clone.shift)
+			clone.steps(stored.Steps)
+			err = saveObject(a.sink, fmt.Sprintf("%v/logsbyid/%v", url, l.ID), stored)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return saveObject(a.sink, url+"/alllogs", logs)
+}
+
+// augmentNameCounts adds the names of count clones to the name counts stored at url, if the export holds them
+func (a *Augmenter) augmentNameCounts(url string, count int, name func(i int) string) (err error) {
+
+	var response struct {
+		Items      []*NameCount         `json:"items"`
+		Pagination contracts.Pagination `json:"pagination"`
+	}
+	ok, err := a.readFixture(url, &response)
+	if err != nil || !ok {
+		return
+	}
+
+	counts := map[string]int{}
+	for _, item := range response.Items {
+		counts[item.Name] = item.Count
+	}
+	for i := 0; i < count; i++ {
+		counts[name(i)]++
+	}
+	response.Items = nameCounts(counts)
+	response.Pagination = singlePage(len(response.Items))
+
+	a.replaced[url] = true
+
+	return saveObject(a.sink, url, response)
+}
+
+// augmentStats adds the durations and resource usage of the cloned builds and releases to the stats of the pipeline at url; clones
+// take the resource usage of a random exported run, of the same release target for releases
+func (a *Augmenter) augmentStats(random *rand.Rand, url string, clonedBuilds []*contracts.Build, clonedReleases []*contracts.Release) (err error) {
+
+	buildDurations := []*DurationMeasurement{}
+	buildMeasurements := []*ResourceMeasurement{}
+	for _, b := range clonedBuilds {
+		buildDurations = append(buildDurations, &DurationMeasurement{InsertedAt: b.InsertedAt, Duration: b.Duration, PendingDuration: b.PendingDuration})
+		buildMeasurements = append(buildMeasurements, &ResourceMeasurement{InsertedAt: b.InsertedAt})
+	}
+
+	releaseDurations := []*DurationMeasurement{}
+	releaseMeasurements := []*ResourceMeasurement{}
+	for _, r := range clonedReleases {
+		if r.InsertedAt == nil || r.Duration == nil {
+			continue
+		}
+		releaseDurations = append(releaseDurations, &DurationMeasurement{Name: r.Name, InsertedAt: *r.InsertedAt, Duration: *r.Duration, PendingDuration: r.PendingDuration})
+		releaseMeasurements = append(releaseMeasurements, &ResourceMeasurement{Name: r.Name, InsertedAt: *r.InsertedAt})
+	}
+
+	for subPath, durations := range map[string][]*DurationMeasurement{
+		"stats/buildsdurations":   buildDurations,
+		"stats/releasesdurations": releaseDurations,
+	} {
+		err = a.augmentDurations(url+"/"+subPath, durations)
+		if err != nil {
+			return
+		}
+	}
+
+	// in a fixed order, so the same seed picks the same measurements
+	for _, subPath := range []string{"stats/buildscpu", "stats/buildsmemory"} {
+		err = a.augmentMeasurements(random, url+"/"+subPath, buildMeasurements)
+		if err != nil {
+			return
+		}
+	}
+	for _, subPath := range []string{"stats/releasescpu", "stats/releasesmemory"} {
+		err = a.augmentMeasurements(random, url+"/"+subPath, releaseMeasurements)
+		if err != nil {
+			return
+		}
+	}
+
+	return nil
+}
+
+// augmentDurations appends the durations of clones to the durations stored at url, if the export holds them
+func (a *Augmenter) augmentDurations(url string, durations []*DurationMeasurement) (err error) {
+
+	var response PipelineDurationsResponse
+	ok, err := a.readFixture(url, &response)
+	if err != nil || !ok {
+		return
+	}
+
+	// clones are older than every exported run, like in the lists
+	response.Durations = append(response.Durations, durations...)
+
+	a.replaced[url] = true
+
+	return saveObject(a.sink, url, response)
+}
+
+// augmentMeasurements appends a measurement for every clone to the measurements stored at url, if the export holds them, taking
+// the values of a random exported measurement with the same name
+func (a *Augmenter) augmentMeasurements(random *rand.Rand, url string, clones []*ResourceMeasurement) (err error) {
+
+	var response PipelineMeasurementsResponse
+	ok, err := a.readFixture(url, &response)
+	if err != nil || !ok {
+		return
+	}
+
+	sourcesByName := map[string][]*ResourceMeasurement{}
+	for _, m := range response.Measurements {
+		sourcesByName[m.Name] = append(sourcesByName[m.Name], m)
+	}
+	for _, clone := range clones {
+		sources := sourcesByName[clone.Name]
+		if len(sources) == 0 {
+			continue
+		}
+		source := sources[random.Intn(len(sources))]
+		response.Measurements = append(response.Measurements, &ResourceMeasurement{
+			Name:           clone.Name,
+			InsertedAt:     clone.InsertedAt,
+			MaxCPUUsage:    source.MaxCPUUsage,
+			MaxMemoryUsage: source.MaxMemoryUsage,
+		})
+	}
+
+	a.replaced[url] = true
+
+	return saveObject(a.sink, url, response)
+}
+
+// augmentPipelineCounts adds the number of clones by pipeline path to the pipeline counts stored at url, if the export holds them
+func (a *Augmenter) augmentPipelineCounts(url string, clonedCounts map[string]int) (err error) {
+
+	var response PipelineCountsListResponse
+	ok, err := a.readFixture(url, &response)
+	if err != nil || !ok {
+		return
+	}
+
+	for _, item := range response.Items {
+		item.NrRecords += clonedCounts[item.RepoSource+"/"+item.RepoOwner+"/"+item.RepoName]
+	}
+	sort.SliceStable(response.Items, func(i, j int) bool { return response.Items[i].NrRecords > response.Items[j].NrRecords })
+	response.Pagination = singlePage(len(response.Items))
+
+	return a.saveList(url, response, response.Items)
+}
+
+// recountReleaseTargets stores the release targets of the export with the number of augmented pipelines releasing to them, if
+// the export holds them
+func (a *Augmenter) recountReleaseTargets(pipelines map[string]*contracts.Pipeline) (err error) {
+
+	var response ReleaseTargetsListResponse
+	ok, err := a.readFixture("/api/releasetargets", &response)
+	if err != nil || !ok {
+		return
+	}
+
+	counts := map[string]int{}
+	for _, p := range pipelines {
+		for _, target := range p.ReleaseTargets {
+			counts[target.Name]++
+		}
+	}
+	items := []*ReleaseTargetCount{}
+	for _, item := range response.Items {
+		if counts[item.Name] > 0 {
+			items = append(items, &ReleaseTargetCount{Name: item.Name, PipelinesCount: counts[item.Name]})
+		}
+	}
+	response.Items = items
+	response.Pagination = singlePage(len(response.Items))
+
+	return a.saveList("/api/releasetargets", response, response.Items)
+}
+
+// copyExport stores every fixture of the export the augmenter didn't replace; mocker handlers and the manifest get recreated by the sink
+func (a *Augmenter) copyExport() error {
+
+	return filepath.Walk(a.directory, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(a.directory, filePath)
+		if err != nil {
+			return err
+		}
+		directory, name := path.Split(filepath.ToSlash(relativePath))
+		url := "/" + strings.TrimSuffix(directory, "/")
+		if a.replaced[url] {
+			return nil
+		}
+
+		pageNumber, pageSize, isPage := parsePageFileName(name)
+		if name != "index.json" && !isPage {
+			return nil
+		}
+
+		bytes, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case isPage:
+			return a.sink.WriteJSON(url, pageQuery(pageNumber, pageSize), bytes)
+		case strings.HasSuffix(url, "/logs.stream"):
+			return a.sink.WriteSSE(url, bytes)
+		default:
+			return a.sink.WriteJSON(url, nil, bytes)
+		}
+	})
+}
+
+// readFixture decodes the response stored for url into target, returning false if the export doesn't hold it
+func (a *Augmenter) readFixture(url string, target interface{}) (bool, error) {
+	err := readJSONFile(filepath.Join(a.directory, filepath.FromSlash(fixtureFileName(url, nil))), target)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// copyFixture stores the response stored for sourceURL at url as it is, if the export holds it
+func (a *Augmenter) copyFixture(sourceURL, url string) error {
+	bytes, err := ioutil.ReadFile(filepath.Join(a.directory, filepath.FromSlash(fixtureFileName(sourceURL, nil))))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return saveBytes(a.sink, url, bytes)
+}
+
+// saveList stores a list the augmenter replaces, so copying the export skips the stored list and its pages
+func (a *Augmenter) saveList(url string, list interface{}, items interface{}) error {
+	a.replaced[url] = true
+	return saveList(a.sink, url, list, items, a.options.PageSize)
+}
+
+// newID returns the next id for a clone or its logs
+func (a *Augmenter) newID() string {
+	id := a.nextID
+	a.nextID++
+	return strconv.Itoa(id)
+}
+
+// runClone describes how a cloned build, release or bot differs from the run it's cloned from
+type runClone struct {
+	id       string
+	shift    time.Duration
+	version  string
+	revision string
+	commits  []contracts.GitCommit
+	replacer *strings.Replacer
+}
+
+// steps moves the log lines of steps by the shift of the clone and replaces the version and revision of the source in them
+func (c runClone) steps(steps []*contracts.BuildLogStep) {
+	for _, step := range steps {
+		for i := range step.LogLines {
+			step.LogLines[i].Timestamp = step.LogLines[i].Timestamp.Add(c.shift)
+			if c.replacer != nil {
+				step.LogLines[i].Text = c.replacer.Replace(step.LogLines[i].Text)
+			}
+		}
+		c.steps(step.NestedSteps)
+		c.steps(step.Services)
+	}
+}
+
+// newCloneReplacer returns a replacer for pairs of old and new values, skipping empty and unchanged ones
+func newCloneReplacer(oldNew ...string) *strings.Replacer {
+	pairs := []string{}
+	for i := 0; i+1 < len(oldNew); i += 2 {
+		if oldNew[i] != "" && oldNew[i] != oldNew[i+1] {
+			pairs = append(pairs, oldNew[i], oldNew[i+1])
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// augmentedVersion returns the version of the build n builds before the build with version base, keeping the suffix of the
+// version of the cloned build, like the branch of a feature branch build
+func augmentedVersion(base, source string, n int) string {
+
+	baseMatch := versionRegex.FindStringSubmatch(base)
+	if baseMatch == nil {
+		return fmt.Sprintf("%v-%v", source, n)
+	}
+
+	suffix := ""
+	if sourceMatch := versionRegex.FindStringSubmatch(source); sourceMatch != nil {
+		suffix = sourceMatch[4]
+	}
+
+	// the patch number counts down, borrowing a hundred from the minor number, or the major number, once it runs out
+	major, _ := strconv.Atoi(baseMatch[1])
+	minor, _ := strconv.Atoi(baseMatch[2])
+	patch, _ := strconv.Atoi(baseMatch[3])
+	patch -= n
+	for patch < 0 && (minor > 0 || major > 0) {
+		if minor == 0 {
+			major--
+			minor = 100
+		}
+		minor--
+		patch += 100
+	}
+	if patch < 0 {
+		patch = 0
+	}
+
+	return fmt.Sprintf("%v.%v.%v%v", major, minor, patch, suffix)
+}
+
+// cloneCommits returns between one and three commits recombined from commits, or generated ones if there are none
+func cloneCommits(random *rand.Rand, commits []contracts.GitCommit) []contracts.GitCommit {
+	if len(commits) == 0 {
+		return randomCommits(random)
+	}
+	cloned := []contracts.GitCommit{}
+	for i := 0; i < 1+random.Intn(3); i++ {
+		commit := commits[random.Intn(len(commits))]
+		commit.Author = commits[random.Intn(len(commits))].Author
+		cloned = append(cloned, commit)
+	}
+	return cloned
+}
+
+// runInterval returns the average time between times, or fallback if that can't be determined
+func runInterval(times []time.Time, fallback time.Duration) time.Duration {
+	if len(times) < 2 {
+		return fallback
+	}
+	sorted := append([]time.Time{}, times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+	interval := sorted[len(sorted)-1].Sub(sorted[0]) / time.Duration(len(sorted)-1)
+	if interval <= 0 {
+		return fallback
+	}
+	return interval
+}
+
+// earliest returns the earliest of times
+func earliest(times []time.Time) time.Time {
+	min := times[0]
+	for _, t := range times[1:] {
+		if t.Before(min) {
+			min = t
+		}
+	}
+	return min
+}
+
+// jitter returns a random offset of at most a quarter of interval either way
+func jitter(random *rand.Rand, interval time.Duration) time.Duration {
+	quarter := int64(interval / 4)
+	if quarter <= 0 {
+		return 0
+	}
+	return time.Duration(random.Int63n(2*quarter) - quarter).Round(time.Second)
+}
+
+func shiftTime(t *time.Time, shift time.Duration) *time.Time {
+	if t == nil {
+		return nil
+	}
+	shifted := t.Add(shift)
+	return &shifted
+}
+
+func isFinished(status contracts.Status) bool {
+	return status == contracts.StatusSucceeded || status == contracts.StatusFailed || status == contracts.StatusCanceled
+}
+
+// cloneJSON deep copies source into target through their json representation
+func cloneJSON(source, target interface{}) error {
+	bytes, err := json.Marshal(source)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, target)
+}
+
+// maxExportedID returns the highest numeric build, release, bot or log id in the paths of the export in directory
+func maxExportedID(directory string) (maxID int, err error) {
+
+	err = filepath.Walk(filepath.Join(directory, "api", "pipelines"), func(filePath string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		}
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		switch filepath.Base(filepath.Dir(filePath)) {
+		case "builds", "releases", "bots", "logsbyid":
+			if id, err := strconv.Atoi(info.Name()); err == nil && id > maxID {
+				maxID = id
+			}
+		}
+		return nil
+	})
+
+	return maxID, err
+}
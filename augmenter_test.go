@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestExport generates an export of a single pipeline into a temporary directory and returns the directory and pipeline path
+func newTestExport(t *testing.T) (string, string) {
+
+	directory, err := ioutil.TempDir("", "augment")
+	assert.Nil(t, err)
+	generator := NewGenerator(NewDirectorySink(directory), GeneratorOptions{Seed: 3, ReferenceTime: testReferenceTime, Pipelines: 1, BuildsPerPipeline: 6})
+	err = generator.Run(context.Background())
+	assert.Nil(t, err)
+
+	return directory, generator.PipelinePaths()[0]
+}
+
+func TestAugmenterRun(t *testing.T) {
+	t.Run("AddsClonesOlderThanExportedBuilds", func(t *testing.T) {
+
+		directory, path := newTestExport(t)
+		defer os.RemoveAll(directory)
+		var exported PipelineBuildsListResponse
+		err := readExportedList(directory, path, "builds", &exported)
+		assert.Nil(t, err)
+		sink := NewMemorySink()
+		augmenter := NewAugmenter(directory, sink, AugmenterOptions{Seed: 1, BuildsPerPipeline: 4, ReleasesPerTarget: 2, BotsPerPipeline: 2})
+
+		// act
+		err = augmenter.Run(context.Background())
+
+		assert.Nil(t, err)
+		files := sink.Files()
+		url := "api/pipelines/" + path
+		var builds PipelineBuildsListResponse
+		err = json.Unmarshal(files[url+"/builds/index.json"], &builds)
+		assert.Nil(t, err)
+		assert.Equal(t, len(exported.Items)+4, len(builds.Items))
+		assert.Equal(t, len(builds.Items), builds.Pagination.TotalItems)
+		oldest := exported.Items[len(exported.Items)-1]
+		ids := map[string]bool{}
+		for _, b := range builds.Items {
+			assert.False(t, ids[b.ID], b.ID)
+			ids[b.ID] = true
+		}
+		for i, clone := range builds.Items[len(exported.Items):] {
+			assert.True(t, clone.InsertedAt.Before(oldest.InsertedAt))
+			assert.True(t, i == 0 || clone.InsertedAt.Before(builds.Items[len(exported.Items)+i-1].InsertedAt))
+			assert.NotEqual(t, contracts.StatusRunning, clone.BuildStatus)
+
+			var build contracts.Build
+			err = json.Unmarshal(files[url+"/builds/"+clone.ID+"/index.json"], &build)
+			assert.Nil(t, err)
+			assert.Equal(t, clone.BuildVersion, build.BuildVersion)
+			assert.Equal(t, oldest.Manifest, build.Manifest)
+			assert.Equal(t, oldest.ReleaseTargets, build.ReleaseTargets)
+
+			var logs PipelineBuildsLogsListResponse
+			err = json.Unmarshal(files[url+"/builds/"+clone.ID+"/alllogs/index.json"], &logs)
+			assert.Nil(t, err)
+			assert.Equal(t, clone.ID, logs.Items[0].BuildID)
+			assert.False(t, ids[logs.Items[0].ID])
+			assert.Contains(t, files, url+"/builds/"+clone.ID+"/logsbyid/"+logs.Items[0].ID+"/index.json")
+			for _, step := range logs.Items[0].Steps {
+				for _, line := range step.LogLines {
+					assert.True(t, line.Timestamp.Before(oldest.InsertedAt))
+					for _, b := range exported.Items {
+						assert.NotContains(t, line.Text, b.RepoRevision)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("ReleasesClonedBuildsToExportedReleaseTargets", func(t *testing.T) {
+
+		directory, path := newTestExport(t)
+		defer os.RemoveAll(directory)
+		pipelines, err := readExportedPipelines(directory)
+		assert.Nil(t, err)
+		sink := NewMemorySink()
+		augmenter := NewAugmenter(directory, sink, AugmenterOptions{Seed: 1, BuildsPerPipeline: 6, ReleasesPerTarget: 1})
+
+		// act
+		err = augmenter.Run(context.Background())
+
+		assert.Nil(t, err)
+		files := sink.Files()
+		var releases PipelineReleasesListResponse
+		err = json.Unmarshal(files["api/pipelines/"+path+"/releases/index.json"], &releases)
+		assert.Nil(t, err)
+		var builds PipelineBuildsListResponse
+		err = json.Unmarshal(files["api/pipelines/"+path+"/builds/index.json"], &builds)
+		assert.Nil(t, err)
+		versions := map[string]bool{}
+		for _, b := range builds.Items {
+			versions[b.BuildVersion] = true
+		}
+		targets := map[string]bool{}
+		for _, target := range pipelines[path].ReleaseTargets {
+			targets[target.Name] = true
+		}
+		for _, r := range releases.Items {
+			assert.True(t, targets[r.Name], r.Name)
+			assert.True(t, versions[r.ReleaseVersion], r.ReleaseVersion)
+			assert.Contains(t, files, "api/pipelines/"+path+"/releases/"+r.ID+"/index.json")
+		}
+		assert.Equal(t, files["api/pipelines/"+path+"/index.json"], readTestFile(t, directory, "api/pipelines/"+path+"/index.json"))
+	})
+
+	t.Run("CopiesEveryOtherFileOfTheExport", func(t *testing.T) {
+
+		directory, _ := newTestExport(t)
+		defer os.RemoveAll(directory)
+		sink := NewMemorySink()
+		augmenter := NewAugmenter(directory, sink, AugmenterOptions{Seed: 1})
+
+		// act
+		err := augmenter.Run(context.Background())
+
+		assert.Nil(t, err)
+		files := sink.Files()
+		assert.Equal(t, readTestFile(t, directory, "api/catalog/entities/index.json"), files["api/catalog/entities/index.json"])
+		assert.Equal(t, readTestFile(t, directory, "api/organizations/index.json"), files["api/organizations/index.json"])
+		for name := range files {
+			assert.False(t, strings.HasPrefix(name, "manifest"), name)
+		}
+	})
+
+	t.Run("RecomputesStatsAndInsightsFromAugmentedRuns", func(t *testing.T) {
+
+		directory, path := newTestExport(t)
+		defer os.RemoveAll(directory)
+		sink := NewMemorySink()
+		augmenter := NewAugmenter(directory, sink, AugmenterOptions{Seed: 1, BuildsPerPipeline: 4, ReleasesPerTarget: 2})
+
+		// act
+		err := augmenter.Run(context.Background())
+
+		assert.Nil(t, err)
+		files := sink.Files()
+		url := "api/pipelines/" + path
+		var builds PipelineBuildsListResponse
+		err = json.Unmarshal(files[url+"/builds/index.json"], &builds)
+		assert.Nil(t, err)
+		var releases PipelineReleasesListResponse
+		err = json.Unmarshal(files[url+"/releases/index.json"], &releases)
+		assert.Nil(t, err)
+
+		var mostBuilds PipelineCountsListResponse
+		err = json.Unmarshal(files["api/stats/mostbuilds/index.json"], &mostBuilds)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(mostBuilds.Items))
+		assert.Equal(t, len(builds.Items), mostBuilds.Items[0].NrRecords)
+		var mostReleases PipelineCountsListResponse
+		err = json.Unmarshal(files["api/stats/mostreleases/index.json"], &mostReleases)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(mostReleases.Items))
+		assert.Equal(t, len(releases.Items), mostReleases.Items[0].NrRecords)
+
+		oldest := builds.Items[len(builds.Items)-1]
+		var durations PipelineDurationsResponse
+		err = json.Unmarshal(files[url+"/stats/buildsdurations/index.json"], &durations)
+		assert.Nil(t, err)
+		assert.Equal(t, oldest.InsertedAt, durations.Durations[len(durations.Durations)-1].InsertedAt)
+		var cpu PipelineMeasurementsResponse
+		err = json.Unmarshal(files[url+"/stats/buildscpu/index.json"], &cpu)
+		assert.Nil(t, err)
+		assert.Equal(t, len(durations.Durations), len(cpu.Measurements))
+		assert.Equal(t, oldest.InsertedAt, cpu.Measurements[len(cpu.Measurements)-1].InsertedAt)
+		assert.NotNil(t, cpu.Measurements[len(cpu.Measurements)-1].MaxCPUUsage)
+		var releaseDurations PipelineDurationsResponse
+		err = json.Unmarshal(files[url+"/stats/releasesdurations/index.json"], &releaseDurations)
+		assert.Nil(t, err)
+		assert.Equal(t, len(releases.Items), len(releaseDurations.Durations))
+
+		var targets ReleaseTargetsListResponse
+		err = json.Unmarshal(files["api/releasetargets/index.json"], &targets)
+		assert.Nil(t, err)
+		for _, target := range targets.Items {
+			assert.Equal(t, 1, target.PipelinesCount, target.Name)
+		}
+	})
+
+	t.Run("ReturnsErrorForDirectoryWithoutExport", func(t *testing.T) {
+
+		directory, err := ioutil.TempDir("", "augment")
+		assert.Nil(t, err)
+		defer os.RemoveAll(directory)
+
+		// act
+		err = NewAugmenter(directory, NewMemorySink(), AugmenterOptions{}).Run(context.Background())
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestAugmentedVersion(t *testing.T) {
+	t.Run("CountsDownPatchNumberKeepingSuffixOfSource", func(t *testing.T) {
+
+		// act
+		version := augmentedVersion("1.4.12", "1.4.20-feature-login", 3)
+
+		assert.Equal(t, "1.4.9-feature-login", version)
+	})
+
+	t.Run("BorrowsFromMinorAndMajorNumber", func(t *testing.T) {
+
+		// act
+		minorBorrowed := augmentedVersion("1.4.0", "1.4.0", 2)
+		majorBorrowed := augmentedVersion("1.0.0", "1.0.0", 1)
+
+		assert.Equal(t, "1.3.98", minorBorrowed)
+		assert.Equal(t, "0.99.99", majorBorrowed)
+	})
+
+	t.Run("AppendsNumberToOtherVersions", func(t *testing.T) {
+
+		// act
+		version := augmentedVersion("latest", "latest", 2)
+
+		assert.Equal(t, "latest-2", version)
+	})
+}
+
+func readTestFile(t *testing.T, directory, name string) []byte {
+	bytes, err := ioutil.ReadFile(directory + "/" + name)
+	assert.Nil(t, err)
+	return bytes
+}
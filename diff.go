@@ -0,0 +1,501 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+)
+
+// DiffReport describes the semantic changes between two exports
+type DiffReport struct {
+	PipelinesAdded   []string       `json:"pipelinesAdded,omitempty"`
+	PipelinesRemoved []string       `json:"pipelinesRemoved,omitempty"`
+	Pipelines        []PipelineDiff `json:"pipelines,omitempty"`
+	// endpoints outside of the pipelines, like /api/builds, /api/catalog/entities and /api/stats/mostbuilds
+	EndpointsAdded   []string `json:"endpointsAdded,omitempty"`
+	EndpointsRemoved []string `json:"endpointsRemoved,omitempty"`
+	EndpointsChanged []string `json:"endpointsChanged,omitempty"`
+}
+
+// PipelineDiff describes the changes for a pipeline present in both exports
+type PipelineDiff struct {
+	Pipeline        string         `json:"pipeline"`
+	BuildsAdded     []string       `json:"buildsAdded,omitempty"`
+	BuildsRemoved   []string       `json:"buildsRemoved,omitempty"`
+	ReleasesAdded   []string       `json:"releasesAdded,omitempty"`
+	ReleasesRemoved []string       `json:"releasesRemoved,omitempty"`
+	BotsAdded       []string       `json:"botsAdded,omitempty"`
+	BotsRemoved     []string       `json:"botsRemoved,omitempty"`
+	StatusChanges   []StatusChange `json:"statusChanges,omitempty"`
+	LogSizeChanges  []SizeChange   `json:"logSizeChanges,omitempty"`
+	StatsDeltas     []StatsDelta   `json:"statsDeltas,omitempty"`
+}
+
+// StatusChange describes a pipeline, build, release or bot whose status changed
+type StatusChange struct {
+	Resource string `json:"resource"`
+	ID       string `json:"id"`
+	Old      string `json:"old"`
+	New      string `json:"new"`
+}
+
+// SizeChange describes a log whose size in bytes changed
+type SizeChange struct {
+	Path string `json:"path"`
+	Old  int64  `json:"old"`
+	New  int64  `json:"new"`
+}
+
+// StatsDelta describes a numeric value in the pipeline stats that changed
+type StatsDelta struct {
+	Path  string  `json:"path"`
+	Field string  `json:"field"`
+	Old   float64 `json:"old"`
+	New   float64 `json:"new"`
+}
+
+// IsEmpty returns true if the exports don't differ semantically
+func (r DiffReport) IsEmpty() bool {
+	return len(r.PipelinesAdded) == 0 && len(r.PipelinesRemoved) == 0 && len(r.Pipelines) == 0 &&
+		len(r.EndpointsAdded) == 0 && len(r.EndpointsRemoved) == 0 && len(r.EndpointsChanged) == 0
+}
+
+func (d PipelineDiff) isEmpty() bool {
+	return len(d.BuildsAdded) == 0 && len(d.BuildsRemoved) == 0 &&
+		len(d.ReleasesAdded) == 0 && len(d.ReleasesRemoved) == 0 &&
+		len(d.BotsAdded) == 0 && len(d.BotsRemoved) == 0 &&
+		len(d.StatusChanges) == 0 && len(d.LogSizeChanges) == 0 && len(d.StatsDeltas) == 0
+}
+
+// Text returns the report in human-readable form
+func (r DiffReport) Text() string {
+
+	if r.IsEmpty() {
+		return "No changes\n"
+	}
+
+	var sb strings.Builder
+	for _, p := range r.PipelinesAdded {
+		fmt.Fprintf(&sb, "+ pipeline %v\n", p)
+	}
+	for _, p := range r.PipelinesRemoved {
+		fmt.Fprintf(&sb, "- pipeline %v\n", p)
+	}
+
+	for _, d := range r.Pipelines {
+		fmt.Fprintf(&sb, "~ pipeline %v\n", d.Pipeline)
+		writeListChanges(&sb, "build", d.BuildsAdded, d.BuildsRemoved)
+		writeListChanges(&sb, "release", d.ReleasesAdded, d.ReleasesRemoved)
+		writeListChanges(&sb, "bot", d.BotsAdded, d.BotsRemoved)
+		for _, c := range d.StatusChanges {
+			fmt.Fprintf(&sb, "    %v %v status %v -> %v\n", c.Resource, c.ID, c.Old, c.New)
+		}
+		for _, c := range d.LogSizeChanges {
+			fmt.Fprintf(&sb, "    log %v size %v -> %v bytes\n", c.Path, c.Old, c.New)
+		}
+		for _, c := range d.StatsDeltas {
+			fmt.Fprintf(&sb, "    %v %v %v -> %v (%+g)\n", c.Path, c.Field, c.Old, c.New, c.New-c.Old)
+		}
+	}
+
+	for _, e := range r.EndpointsAdded {
+		fmt.Fprintf(&sb, "+ endpoint %v\n", e)
+	}
+	for _, e := range r.EndpointsRemoved {
+		fmt.Fprintf(&sb, "- endpoint %v\n", e)
+	}
+	for _, e := range r.EndpointsChanged {
+		fmt.Fprintf(&sb, "~ endpoint %v\n", e)
+	}
+
+	return sb.String()
+}
+
+// printDiffReport writes the report to w as text or json
+func printDiffReport(w io.Writer, report DiffReport, format string) error {
+	if format == "json" {
+		bytes, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(bytes))
+		return err
+	}
+
+	_, err := fmt.Fprint(w, report.Text())
+	return err
+}
+
+func writeListChanges(sb *strings.Builder, resource string, added, removed []string) {
+	for _, a := range added {
+		fmt.Fprintf(sb, "    + %v %v\n", resource, a)
+	}
+	for _, r := range removed {
+		fmt.Fprintf(sb, "    - %v %v\n", resource, r)
+	}
+}
+
+// diffExports compares the exports stored in oldDirectory and newDirectory semantically
+func diffExports(oldDirectory, newDirectory string) (report DiffReport, err error) {
+
+	oldPipelines, err := readExportedPipelines(oldDirectory)
+	if err != nil {
+		return
+	}
+	newPipelines, err := readExportedPipelines(newDirectory)
+	if err != nil {
+		return
+	}
+
+	for _, path := range sortedKeys(newPipelines) {
+		if _, ok := oldPipelines[path]; !ok {
+			report.PipelinesAdded = append(report.PipelinesAdded, path)
+		}
+	}
+
+	for _, path := range sortedKeys(oldPipelines) {
+		newPipeline, ok := newPipelines[path]
+		if !ok {
+			report.PipelinesRemoved = append(report.PipelinesRemoved, path)
+			continue
+		}
+
+		diff, err := diffPipeline(oldDirectory, newDirectory, oldPipelines[path], newPipeline)
+		if err != nil {
+			return report, err
+		}
+		if !diff.isEmpty() {
+			report.Pipelines = append(report.Pipelines, diff)
+		}
+	}
+
+	paths, err := exportedEndpoints(oldDirectory, newDirectory)
+	if err != nil {
+		return
+	}
+	oldEndpoints, err := readGlobalEndpoints(oldDirectory, paths)
+	if err != nil {
+		return
+	}
+	newEndpoints, err := readGlobalEndpoints(newDirectory, paths)
+	if err != nil {
+		return
+	}
+
+	for _, path := range sortedKeys(newEndpoints) {
+		oldDocument, ok := oldEndpoints[path]
+		switch {
+		case !ok:
+			report.EndpointsAdded = append(report.EndpointsAdded, path)
+		case !reflect.DeepEqual(oldDocument, newEndpoints[path]):
+			report.EndpointsChanged = append(report.EndpointsChanged, path)
+		}
+	}
+	for _, path := range sortedKeys(oldEndpoints) {
+		if _, ok := newEndpoints[path]; !ok {
+			report.EndpointsRemoved = append(report.EndpointsRemoved, path)
+		}
+	}
+
+	return report, nil
+}
+
+func diffPipeline(oldDirectory, newDirectory string, oldPipeline, newPipeline *contracts.Pipeline) (diff PipelineDiff, err error) {
+
+	path := newPipeline.GetFullRepoPath()
+	diff.Pipeline = path
+
+	if oldPipeline.BuildStatus != newPipeline.BuildStatus {
+		diff.StatusChanges = append(diff.StatusChanges, StatusChange{Resource: "pipeline", ID: path, Old: string(oldPipeline.BuildStatus), New: string(newPipeline.BuildStatus)})
+	}
+
+	// builds
+	var oldBuilds, newBuilds PipelineBuildsListResponse
+	err = readExportedList(oldDirectory, path, "builds", &oldBuilds)
+	if err != nil {
+		return
+	}
+	err = readExportedList(newDirectory, path, "builds", &newBuilds)
+	if err != nil {
+		return
+	}
+	oldBuildStatuses, newBuildStatuses := map[string]string{}, map[string]string{}
+	for _, b := range oldBuilds.Items {
+		oldBuildStatuses[b.ID] = string(b.BuildStatus)
+	}
+	for _, b := range newBuilds.Items {
+		newBuildStatuses[b.ID] = string(b.BuildStatus)
+	}
+	diff.BuildsAdded, diff.BuildsRemoved = diffStatuses("build", oldBuildStatuses, newBuildStatuses, &diff.StatusChanges)
+
+	// releases
+	var oldReleases, newReleases PipelineReleasesListResponse
+	err = readExportedList(oldDirectory, path, "releases", &oldReleases)
+	if err != nil {
+		return
+	}
+	err = readExportedList(newDirectory, path, "releases", &newReleases)
+	if err != nil {
+		return
+	}
+	oldReleaseStatuses, newReleaseStatuses := map[string]string{}, map[string]string{}
+	for _, r := range oldReleases.Items {
+		oldReleaseStatuses[r.ID] = string(r.ReleaseStatus)
+	}
+	for _, r := range newReleases.Items {
+		newReleaseStatuses[r.ID] = string(r.ReleaseStatus)
+	}
+	diff.ReleasesAdded, diff.ReleasesRemoved = diffStatuses("release", oldReleaseStatuses, newReleaseStatuses, &diff.StatusChanges)
+
+	// bots
+	var oldBots, newBots PipelineBotsListResponse
+	err = readExportedList(oldDirectory, path, "bots", &oldBots)
+	if err != nil {
+		return
+	}
+	err = readExportedList(newDirectory, path, "bots", &newBots)
+	if err != nil {
+		return
+	}
+	oldBotStatuses, newBotStatuses := map[string]string{}, map[string]string{}
+	for _, b := range oldBots.Items {
+		oldBotStatuses[b.ID] = string(b.BotStatus)
+	}
+	for _, b := range newBots.Items {
+		newBotStatuses[b.ID] = string(b.BotStatus)
+	}
+	diff.BotsAdded, diff.BotsRemoved = diffStatuses("bot", oldBotStatuses, newBotStatuses, &diff.StatusChanges)
+
+	// logs
+	oldLogSizes, err := readLogSizes(oldDirectory, path)
+	if err != nil {
+		return
+	}
+	newLogSizes, err := readLogSizes(newDirectory, path)
+	if err != nil {
+		return
+	}
+	for _, logPath := range sortedKeys(newLogSizes) {
+		if oldSize, ok := oldLogSizes[logPath]; ok && oldSize != newLogSizes[logPath] {
+			diff.LogSizeChanges = append(diff.LogSizeChanges, SizeChange{Path: logPath, Old: oldSize, New: newLogSizes[logPath]})
+		}
+	}
+
+	// stats
+	oldStats, err := readStats(oldDirectory, path)
+	if err != nil {
+		return
+	}
+	newStats, err := readStats(newDirectory, path)
+	if err != nil {
+		return
+	}
+	for _, statsPath := range sortedKeys(newStats) {
+		oldValues, ok := oldStats[statsPath]
+		if !ok {
+			continue
+		}
+		for _, field := range sortedKeys(newStats[statsPath]) {
+			if oldValue, ok := oldValues[field]; ok && oldValue != newStats[statsPath][field] {
+				diff.StatsDeltas = append(diff.StatsDeltas, StatsDelta{Path: statsPath, Field: field, Old: oldValue, New: newStats[statsPath][field]})
+			}
+		}
+	}
+
+	return diff, nil
+}
+
+// diffStatuses returns the ids only present in newStatuses or oldStatuses and adds status changes for ids present in both
+func diffStatuses(resource string, oldStatuses, newStatuses map[string]string, changes *[]StatusChange) (added, removed []string) {
+	for _, id := range sortedKeys(newStatuses) {
+		oldStatus, ok := oldStatuses[id]
+		if !ok {
+			added = append(added, id)
+			continue
+		}
+		if oldStatus != newStatuses[id] {
+			*changes = append(*changes, StatusChange{Resource: resource, ID: id, Old: oldStatus, New: newStatuses[id]})
+		}
+	}
+	for _, id := range sortedKeys(oldStatuses) {
+		if _, ok := newStatuses[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	return
+}
+
+func readExportedPipelines(directory string) (pipelines map[string]*contracts.Pipeline, err error) {
+
+	pipelines = map[string]*contracts.Pipeline{}
+
+	// a missing export counts as an empty one
+	var list PipelinesListResponse
+	err = readJSONFile(filepath.Join(directory, "api", "pipelines", "index.json"), &list)
+	if os.IsNotExist(err) {
+		return pipelines, nil
+	}
+	if err != nil {
+		return
+	}
+
+	for _, p := range list.Items {
+		pipelines[p.GetFullRepoPath()] = p
+	}
+
+	return pipelines, nil
+}
+
+// exportedEndpoints returns the paths of the endpoints outside of /api/pipelines listed in the manifest of either export, leaving
+// out any other files in their directories, like hand-written mocks
+func exportedEndpoints(oldDirectory, newDirectory string) ([]string, error) {
+
+	paths := map[string]bool{}
+	for _, directory := range []string{oldDirectory, newDirectory} {
+		manifest, err := readExportManifest(directory)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range manifest.Endpoints {
+			paths[path] = true
+		}
+	}
+
+	return sortedKeys(paths), nil
+}
+
+// readGlobalEndpoints returns the decoded responses of the endpoints at paths by their path, leaving out the ones the export doesn't hold
+func readGlobalEndpoints(directory string, paths []string) (endpoints map[string]interface{}, err error) {
+
+	endpoints = map[string]interface{}{}
+
+	for _, path := range paths {
+		var document interface{}
+		err = readJSONFile(filepath.Join(directory, filepath.FromSlash(fixtureFileName(path, nil))), &document)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		endpoints[path] = document
+	}
+
+	return endpoints, nil
+}
+
+// readExportedList reads the builds, releases or bots list of a pipeline, leaving response empty if it wasn't exported
+func readExportedList(directory, pipelinePath, list string, response interface{}) error {
+	err := readJSONFile(filepath.Join(directory, "api", "pipelines", filepath.FromSlash(pipelinePath), list, "index.json"), response)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// readLogSizes returns the size of all stored logs of a pipeline by their path relative to the pipeline
+func readLogSizes(directory, pipelinePath string) (sizes map[string]int64, err error) {
+
+	sizes = map[string]int64{}
+	pipelineDirectory := filepath.Join(directory, "api", "pipelines", filepath.FromSlash(pipelinePath))
+
+	err = filepath.Walk(pipelineDirectory, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		relativePath := filepath.ToSlash(strings.TrimPrefix(path, pipelineDirectory+string(filepath.Separator)))
+		if info.Name() == "index.json" && (strings.Contains(relativePath, "/logsbyid/") || strings.Contains(relativePath, "/logs.stream/")) {
+			sizes[strings.TrimSuffix(relativePath, "/index.json")] = info.Size()
+		}
+		return nil
+	})
+
+	return sizes, err
+}
+
+// readStats returns the numeric values in all stored stats of a pipeline, keyed by stats path and field
+func readStats(directory, pipelinePath string) (stats map[string]map[string]float64, err error) {
+
+	stats = map[string]map[string]float64{}
+	statsDirectory := filepath.Join(directory, "api", "pipelines", filepath.FromSlash(pipelinePath), "stats")
+
+	infos, err := ioutil.ReadDir(statsDirectory)
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return
+	}
+
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+
+		var document interface{}
+		err = readJSONFile(filepath.Join(statsDirectory, info.Name(), "index.json"), &document)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+
+		values := map[string]float64{}
+		flattenNumbers("", document, values)
+		stats["stats/"+info.Name()] = values
+	}
+
+	return stats, nil
+}
+
+// flattenNumbers collects all numbers in a json document by their path, like durations[0].duration
+func flattenNumbers(prefix string, document interface{}, values map[string]float64) {
+	switch d := document.(type) {
+	case float64:
+		values[prefix] = d
+	case map[string]interface{}:
+		for k, v := range d {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flattenNumbers(key, v, values)
+		}
+	case []interface{}:
+		for i, v := range d {
+			flattenNumbers(fmt.Sprintf("%v[%v]", prefix, i), v, values)
+		}
+	}
+}
+
+func readJSONFile(path string, target interface{}) error {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bytes, target)
+}
+
+// sortedKeys returns the keys of a map with string keys in sorted order
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffExports(t *testing.T) {
+	t.Run("ReturnsEmptyReportForEqualExports", func(t *testing.T) {
+
+		files := map[string]string{
+			"api/pipelines/index.json": `{"items":[{"repoSource":"github.com","repoOwner":"estafette","repoName":"estafette-ci-api","buildStatus":"succeeded"}]}`,
+		}
+		oldDirectory := createMockDirectory(t, files)
+		defer os.RemoveAll(oldDirectory)
+		newDirectory := createMockDirectory(t, files)
+		defer os.RemoveAll(newDirectory)
+
+		// act
+		report, err := diffExports(oldDirectory, newDirectory)
+
+		assert.Nil(t, err)
+		assert.True(t, report.IsEmpty())
+		assert.Equal(t, "No changes\n", report.Text())
+	})
+
+	t.Run("ReturnsSemanticChanges", func(t *testing.T) {
+
+		oldDirectory := createMockDirectory(t, map[string]string{
+			"api/pipelines/index.json": `{"items":[{"repoSource":"github.com","repoOwner":"estafette","repoName":"estafette-ci-api","buildStatus":"running"},{"repoSource":"github.com","repoOwner":"estafette","repoName":"estafette-ci-old"}]}`,
+			"api/pipelines/github.com/estafette/estafette-ci-api/builds/index.json":                `{"items":[{"id":"1","buildStatus":"running"},{"id":"2","buildStatus":"failed"}]}`,
+			"api/pipelines/github.com/estafette/estafette-ci-api/builds/1/logsbyid/3/index.json":   `{"steps":[]}`,
+			"api/pipelines/github.com/estafette/estafette-ci-api/stats/buildsdurations/index.json": `{"durations":[{"duration":10}]}`,
+		})
+		defer os.RemoveAll(oldDirectory)
+		newDirectory := createMockDirectory(t, map[string]string{
+			"api/pipelines/index.json": `{"items":[{"repoSource":"github.com","repoOwner":"estafette","repoName":"estafette-ci-api","buildStatus":"succeeded"},{"repoSource":"github.com","repoOwner":"estafette","repoName":"estafette-ci-new"}]}`,
+			"api/pipelines/github.com/estafette/estafette-ci-api/builds/index.json":                `{"items":[{"id":"1","buildStatus":"succeeded"},{"id":"4","buildStatus":"running"}]}`,
+			"api/pipelines/github.com/estafette/estafette-ci-api/builds/1/logsbyid/3/index.json":   `{"steps":[{"step":"build"}]}`,
+			"api/pipelines/github.com/estafette/estafette-ci-api/stats/buildsdurations/index.json": `{"durations":[{"duration":12}]}`,
+		})
+		defer os.RemoveAll(newDirectory)
+
+		// act
+		report, err := diffExports(oldDirectory, newDirectory)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"github.com/estafette/estafette-ci-new"}, report.PipelinesAdded)
+		assert.Equal(t, []string{"github.com/estafette/estafette-ci-old"}, report.PipelinesRemoved)
+		if assert.Equal(t, 1, len(report.Pipelines)) {
+			diff := report.Pipelines[0]
+			assert.Equal(t, []string{"4"}, diff.BuildsAdded)
+			assert.Equal(t, []string{"2"}, diff.BuildsRemoved)
+			assert.Equal(t, []StatusChange{{Resource: "pipeline", ID: "github.com/estafette/estafette-ci-api", Old: "running", New: "succeeded"}, {Resource: "build", ID: "1", Old: "running", New: "succeeded"}}, diff.StatusChanges)
+			assert.Equal(t, []SizeChange{{Path: "builds/1/logsbyid/3", Old: 12, New: 28}}, diff.LogSizeChanges)
+			assert.Equal(t, []StatsDelta{{Path: "stats/buildsdurations", Field: "durations[0].duration", Old: 10, New: 12}}, diff.StatsDeltas)
+		}
+		assert.Contains(t, report.Text(), "build 1 status running -> succeeded")
+	})
+
+	t.Run("ReturnsChangedGlobalEndpoints", func(t *testing.T) {
+
+		oldDirectory := createMockDirectory(t, map[string]string{
+			"api/stats/mostbuilds/index.json":   `{"items":[{"pipeline":"estafette-ci-api","nr":10}]}`,
+			"api/labels/frequent/index.json":    `{"items":[]}`,
+			"api/organizations/index.json":      `{"items":[]}`,
+			"api/builds/page-1-size-2.json":     `{"items":[]}`,
+			"api/pipelines/index.json":          `{"items":[]}`,
+			"api/releasetargets/index.json":     `{"items":[{"name":"production"}]}`,
+			"api/catalog/entities/index.json":   `{"items":[]}`,
+			"api/catalog/filters/GET.js":        `module.exports = function () {}`,
+			"api/catalog/entitykeys/index.json": `{"items":[]}`,
+			"api/users/me/index.json":           `{"email":"me@estafette.io"}`,
+			"manifest.json":                     `{"endpoints":["/api/stats/mostbuilds","/api/labels/frequent","/api/organizations","/api/releasetargets","/api/catalog/entities","/api/catalog/entitykeys"]}`,
+		})
+		defer os.RemoveAll(oldDirectory)
+		newDirectory := createMockDirectory(t, map[string]string{
+			"api/stats/mostbuilds/index.json":   `{"items":[{"pipeline":"estafette-ci-api","nr":30}]}`,
+			"api/labels/frequent/index.json":    `{"items":[]}`,
+			"api/builds/index.json":             `{"items":[]}`,
+			"api/pipelines/index.json":          `{"items":[]}`,
+			"api/releasetargets/index.json":     `{"items":[{"name":"production"}]}`,
+			"api/catalog/entities/index.json":   `{"items":[]}`,
+			"api/catalog/entitykeys/index.json": `{ "items": [] }`,
+			"manifest.json":                     `{"endpoints":["/api/stats/mostbuilds","/api/labels/frequent","/api/builds","/api/releasetargets","/api/catalog/entities","/api/catalog/entitykeys"]}`,
+		})
+		defer os.RemoveAll(newDirectory)
+
+		// act
+		report, err := diffExports(oldDirectory, newDirectory)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"/api/builds"}, report.EndpointsAdded)
+		assert.Equal(t, []string{"/api/organizations"}, report.EndpointsRemoved)
+		assert.Equal(t, []string{"/api/stats/mostbuilds"}, report.EndpointsChanged)
+		assert.False(t, report.IsEmpty())
+		assert.Contains(t, report.Text(), "~ endpoint /api/stats/mostbuilds")
+	})
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"time"
+
 	contracts "github.com/estafette/estafette-ci-contracts"
 )
 
@@ -38,3 +40,98 @@ type PipelineBotsLogsListResponse struct {
 	Items      []*contracts.BotLog  `json:"items"`
 	Pagination contracts.Pagination `json:"pagination"`
 }
+
+type PipelineBuildBranchesListResponse struct {
+	Items      []*NameCount         `json:"items"`
+	Pagination contracts.Pagination `json:"pagination"`
+}
+
+type PipelineBotNamesListResponse struct {
+	Items      []*NameCount         `json:"items"`
+	Pagination contracts.Pagination `json:"pagination"`
+}
+
+// NameCount is a distinct value with the number of builds or bots it occurs in, as returned by buildbranches and botnames
+type NameCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type PipelineWarningsResponse struct {
+	Warnings []*contracts.Warning `json:"warnings"`
+}
+
+type PipelineDurationsResponse struct {
+	Durations []*DurationMeasurement `json:"durations"`
+}
+
+// DurationMeasurement is a single data point from the stats/buildsdurations and stats/releasesdurations endpoints
+type DurationMeasurement struct {
+	Name            string         `json:"name,omitempty"`
+	InsertedAt      time.Time      `json:"insertedAt"`
+	Duration        time.Duration  `json:"duration"`
+	PendingDuration *time.Duration `json:"pendingDuration,omitempty"`
+}
+
+type PipelineMeasurementsResponse struct {
+	Measurements []*ResourceMeasurement `json:"measurements"`
+}
+
+// ResourceMeasurement is a single data point from the stats/*cpu and stats/*memory endpoints
+type ResourceMeasurement struct {
+	Name           string    `json:"name,omitempty"`
+	InsertedAt     time.Time `json:"insertedAt"`
+	MaxCPUUsage    *float64  `json:"maxCpuUsage,omitempty"`
+	MaxMemoryUsage *float64  `json:"maxMemoryUsage,omitempty"`
+}
+
+type CatalogEntitiesListResponse struct {
+	Items      []*contracts.CatalogEntity `json:"items"`
+	Pagination contracts.Pagination       `json:"pagination"`
+}
+
+type LabelsListResponse struct {
+	Items      []*contracts.Label   `json:"items"`
+	Pagination contracts.Pagination `json:"pagination"`
+}
+
+type FrequentLabelsListResponse struct {
+	Items      []*LabelCount        `json:"items"`
+	Pagination contracts.Pagination `json:"pagination"`
+}
+
+// LabelCount is a label with the number of pipelines it's set on, as returned by labels/frequent
+type LabelCount struct {
+	Key            string `json:"key"`
+	Value          string `json:"value"`
+	PipelinesCount int    `json:"pipelinesCount"`
+}
+
+type PipelineCountsListResponse struct {
+	Items      []*PipelineCount     `json:"items"`
+	Pagination contracts.Pagination `json:"pagination"`
+}
+
+// PipelineCount is a pipeline with its number of builds or releases, as returned by stats/mostbuilds and stats/mostreleases
+type PipelineCount struct {
+	RepoSource string `json:"repoSource"`
+	RepoOwner  string `json:"repoOwner"`
+	RepoName   string `json:"repoName"`
+	NrRecords  int    `json:"nrRecords"`
+}
+
+type ReleaseTargetsListResponse struct {
+	Items      []*ReleaseTargetCount `json:"items"`
+	Pagination contracts.Pagination  `json:"pagination"`
+}
+
+// ReleaseTargetCount is a release target with the number of pipelines releasing to it, as returned by releasetargets
+type ReleaseTargetCount struct {
+	Name           string `json:"name"`
+	PipelinesCount int    `json:"pipelinesCount"`
+}
+
+type OrganizationsListResponse struct {
+	Items      []*contracts.Organization `json:"items"`
+	Pagination contracts.Pagination      `json:"pagination"`
+}
@@ -0,0 +1,564 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultExtractConcurrency = 10
+	defaultMaxStreamEvents    = 200
+)
+
+// ExtractorOptions configures what an Extractor exports and how
+type ExtractorOptions struct {
+	ClientID     string
+	ClientSecret string
+
+	// Pipelines holds the source/owner/name paths of the pipelines to export
+	Pipelines []string
+
+	// PageSize splits exported lists into pages of this size next to the full list, 0 disables paging
+	PageSize int
+
+	// Concurrency limits the number of builds, releases and bots exported at once, defaults to 10
+	Concurrency int
+
+	// MaxStreamEvents limits the number of events stored for the log stream of a build, release or bot in progress, defaults to 200
+	MaxStreamEvents int
+
+	// SchemaDrift holds the fields unknown to the contracts found by a strict api client, which get reported at the end of the run
+	SchemaDrift *SchemaDrift
+
+	// PreserveUnknownFields stores the fields in SchemaDrift along with the typed responses instead of dropping them
+	PreserveUnknownFields bool
+
+	// RawResponses holds the raw responses kept by the api client; if set, responses stored as fetched are obfuscated in their raw form
+	// to keep field order, nulls and number formats
+	RawResponses *RawResponses
+}
+
+// Extractor exports obfuscated api responses for a set of pipelines into a sink
+type Extractor struct {
+	apiClient  ApiClient
+	sink       FixtureSink
+	obfuscator *Obfuscator
+	options    ExtractorOptions
+
+	mutex     sync.Mutex
+	token     string
+	pipelines []*contracts.Pipeline
+	builds    []*contracts.Build
+	releases  []*contracts.Release
+}
+
+// NewExtractor returns an Extractor fetching from apiClient and storing into sink
+func NewExtractor(apiClient ApiClient, sink FixtureSink, obfuscator *Obfuscator, options ExtractorOptions) *Extractor {
+
+	if options.Concurrency <= 0 {
+		options.Concurrency = defaultExtractConcurrency
+	}
+	if options.MaxStreamEvents <= 0 {
+		options.MaxStreamEvents = defaultMaxStreamEvents
+	}
+
+	return &Extractor{
+		apiClient:  apiClient,
+		sink:       sink,
+		obfuscator: obfuscator,
+		options:    options,
+	}
+}
+
+// Run exports all pipelines in the options, followed by the lists across them and the catalog and insights, and finalizes the sink
+func (e *Extractor) Run(ctx context.Context) (err error) {
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Extract")
+	defer span.Finish()
+
+	for _, p := range e.options.Pipelines {
+		err = e.ExtractPipeline(ctx, p)
+		if err != nil {
+			return
+		}
+	}
+
+	token, err := e.authenticate(ctx)
+	if err != nil {
+		return
+	}
+
+	if len(e.pipelines) > 0 {
+		pipelines := PipelinesListResponse{
+			Items: e.pipelines,
+			Pagination: contracts.Pagination{
+				Page:       1,
+				Size:       12,
+				TotalItems: len(e.pipelines),
+				TotalPages: 1,
+			},
+		}
+
+		err = e.saveList("/api/pipelines", pipelines, pipelines.Items)
+		if err != nil {
+			return
+		}
+	}
+
+	// store builds and releases across all exported pipelines
+	builds := aggregateBuilds(e.builds)
+	err = e.saveList("/api/builds", builds, builds.Items)
+	if err != nil {
+		return
+	}
+
+	releases := aggregateReleases(e.releases)
+	err = e.saveList("/api/releases", releases, releases.Items)
+	if err != nil {
+		return
+	}
+
+	err = e.extractGlobals(ctx, token, e.pipelines)
+	if err != nil {
+		return
+	}
+
+	if e.options.SchemaDrift != nil {
+		e.options.SchemaDrift.Log()
+	}
+
+	return e.sink.Finalize()
+}
+
+// ExtractPipeline exports a single pipeline with its builds, releases, bots, logs and stats
+func (e *Extractor) ExtractPipeline(ctx context.Context, path string) (err error) {
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ExtractPipeline")
+	defer span.Finish()
+	span.SetTag("pipeline", path)
+
+	token, err := e.authenticate(ctx)
+	if err != nil {
+		return
+	}
+
+	pipeline, err := e.apiClient.GetPipeline(ctx, token, path)
+	if err != nil || pipeline == nil {
+		return
+	}
+
+	e.obfuscator.Pipeline(pipeline)
+
+	err = e.saveObject("/api/pipelines/"+path, pipeline)
+	if err != nil {
+		return
+	}
+
+	// store builds json
+	builds, err := e.apiClient.GetPipelineBuilds(ctx, token, path)
+	if err != nil {
+		return
+	}
+	builds.Pagination.TotalPages = 1
+	builds.Pagination.TotalItems = len(builds.Items)
+
+	for _, b := range builds.Items {
+		e.obfuscator.Build(b)
+	}
+
+	err = e.saveList(fmt.Sprintf("/api/pipelines/%v/builds", path), builds, builds.Items)
+	if err != nil {
+		return
+	}
+
+	// store releases json
+	releases, err := e.apiClient.GetPipelineReleases(ctx, token, path)
+	if err != nil {
+		return
+	}
+	releases.Pagination.TotalPages = 1
+	releases.Pagination.TotalItems = len(releases.Items)
+
+	for _, r := range releases.Items {
+		e.obfuscator.Release(r)
+	}
+
+	err = e.saveList(fmt.Sprintf("/api/pipelines/%v/releases", path), releases, releases.Items)
+	if err != nil {
+		return
+	}
+
+	// store bots json
+	bots, err := e.apiClient.GetPipelineBots(ctx, token, path)
+	if err != nil {
+		return
+	}
+	bots.Pagination.TotalPages = 1
+	bots.Pagination.TotalItems = len(bots.Items)
+
+	for _, b := range bots.Items {
+		e.obfuscator.Bot(b)
+	}
+
+	err = e.saveList(fmt.Sprintf("/api/pipelines/%v/bots", path), bots, bots.Items)
+	if err != nil {
+		return
+	}
+
+	group := newWorkGroup(e.options.Concurrency)
+
+	for _, b := range builds.Items {
+		b := b
+		group.Go(func() error { return e.extractBuild(ctx, token, path, b) })
+	}
+	for _, r := range releases.Items {
+		r := r
+		group.Go(func() error { return e.extractRelease(ctx, token, path, r) })
+	}
+	for _, b := range bots.Items {
+		b := b
+		group.Go(func() error { return e.extractBot(ctx, token, path, b) })
+	}
+
+	pipelineSubResources := map[string]func() (interface{}, error){
+		"buildbranches": func() (interface{}, error) { return e.apiClient.GetPipelineBuildBranches(ctx, token, path) },
+		"botnames":      func() (interface{}, error) { return e.apiClient.GetPipelineBotNames(ctx, token, path) },
+		"warnings": func() (interface{}, error) {
+			warnings, err := e.apiClient.GetPipelineWarnings(ctx, token, path)
+			e.obfuscator.Warnings(warnings.Warnings)
+			return warnings, err
+		},
+		"stats/buildsdurations":   func() (interface{}, error) { return e.apiClient.GetPipelineStatsBuildsDurations(ctx, token, path) },
+		"stats/buildscpu":         func() (interface{}, error) { return e.apiClient.GetPipelineStatsBuildsCPU(ctx, token, path) },
+		"stats/buildsmemory":      func() (interface{}, error) { return e.apiClient.GetPipelineStatsBuildsMemory(ctx, token, path) },
+		"stats/releasesdurations": func() (interface{}, error) { return e.apiClient.GetPipelineStatsReleasesDurations(ctx, token, path) },
+		"stats/releasescpu":       func() (interface{}, error) { return e.apiClient.GetPipelineStatsReleasesCPU(ctx, token, path) },
+		"stats/releasesmemory":    func() (interface{}, error) { return e.apiClient.GetPipelineStatsReleasesMemory(ctx, token, path) },
+	}
+	for subPath, get := range pipelineSubResources {
+		url, get := fmt.Sprintf("/api/pipelines/%v/%v", path, subPath), get
+		group.Go(func() error {
+			response, err := get()
+			if err != nil {
+				return err
+			}
+			return e.saveObject(url, response)
+		})
+	}
+
+	err = group.Wait()
+	if err != nil {
+		return
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.pipelines = append(e.pipelines, pipeline)
+	e.builds = append(e.builds, builds.Items...)
+	e.releases = append(e.releases, releases.Items...)
+
+	return nil
+}
+
+func (e *Extractor) extractBuild(ctx context.Context, token, pipelinePath string, b *contracts.Build) (err error) {
+
+	// store build json
+	url := fmt.Sprintf("/api/pipelines/%v/builds/%v", pipelinePath, b.ID)
+
+	build, err := e.apiClient.GetPipelineBuild(ctx, token, url)
+	if err != nil || build == nil {
+		return
+	}
+
+	e.obfuscator.Build(build)
+
+	err = e.saveObject(url, build)
+	if err != nil {
+		return
+	}
+
+	// store build warnings json
+	bytes, err := e.apiClient.GetBytesResponse(ctx, token, url+"/warnings")
+	if err != nil {
+		return
+	}
+
+	err = saveBytes(e.sink, url+"/warnings", e.obfuscator.Log(bytes))
+	if err != nil {
+		return
+	}
+
+	// store logs index
+	buildLogs, err := e.apiClient.GetPipelineBuildLogs(ctx, token, url+"/alllogs")
+	if err != nil {
+		return
+	}
+
+	err = e.saveObfuscatedLogs(url+"/alllogs", buildLogs)
+	if err != nil {
+		return
+	}
+
+	logIDs := []string{}
+	for _, bl := range buildLogs.Items {
+		logIDs = append(logIDs, bl.ID)
+	}
+
+	return e.extractLogs(ctx, token, url, build.BuildStatus, logIDs)
+}
+
+func (e *Extractor) extractRelease(ctx context.Context, token, pipelinePath string, r *contracts.Release) (err error) {
+
+	// store release json
+	url := fmt.Sprintf("/api/pipelines/%v/releases/%v", pipelinePath, r.ID)
+
+	release, err := e.apiClient.GetPipelineRelease(ctx, token, url)
+	if err != nil || release == nil {
+		return
+	}
+
+	e.obfuscator.Release(release)
+
+	err = e.saveObject(url, release)
+	if err != nil {
+		return
+	}
+
+	// store logs index
+	releaseLogs, err := e.apiClient.GetPipelineReleaseLogs(ctx, token, url+"/alllogs")
+	if err != nil {
+		return
+	}
+
+	err = e.saveObfuscatedLogs(url+"/alllogs", releaseLogs)
+	if err != nil {
+		return
+	}
+
+	logIDs := []string{}
+	for _, rl := range releaseLogs.Items {
+		logIDs = append(logIDs, rl.ID)
+	}
+
+	return e.extractLogs(ctx, token, url, release.ReleaseStatus, logIDs)
+}
+
+func (e *Extractor) extractBot(ctx context.Context, token, pipelinePath string, b *contracts.Bot) (err error) {
+
+	// store bot json
+	url := fmt.Sprintf("/api/pipelines/%v/bots/%v", pipelinePath, b.ID)
+
+	bot, err := e.apiClient.GetPipelineBot(ctx, token, url)
+	if err != nil || bot == nil {
+		return
+	}
+
+	e.obfuscator.Bot(bot)
+
+	err = e.saveObject(url, bot)
+	if err != nil {
+		return
+	}
+
+	// store logs index
+	botLogs, err := e.apiClient.GetPipelineBotLogs(ctx, token, url+"/alllogs")
+	if err != nil {
+		return
+	}
+
+	err = e.saveObfuscatedLogs(url+"/alllogs", botLogs)
+	if err != nil {
+		return
+	}
+
+	logIDs := []string{}
+	for _, bl := range botLogs.Items {
+		logIDs = append(logIDs, bl.ID)
+	}
+
+	return e.extractLogs(ctx, token, url, bot.BotStatus, logIDs)
+}
+
+// extractLogs stores the log stream of the build, release or bot at url if it's still in progress, or each of its stored logs otherwise
+func (e *Extractor) extractLogs(ctx context.Context, token, url string, status contracts.Status, logIDs []string) (err error) {
+
+	if status == "pending" || status == "running" || status == "canceling" {
+		bytes, err := e.apiClient.GetSSEResponse(ctx, token, url+"/logs.stream", e.options.MaxStreamEvents)
+		if err != nil {
+			return err
+		}
+
+		return saveSSEBytes(e.sink, url+"/logs.stream", e.obfuscator.Log(bytes))
+	}
+
+	for _, id := range logIDs {
+		logURL := fmt.Sprintf("%v/logsbyid/%v", url, id)
+
+		bytes, err := e.apiClient.GetBytesResponse(ctx, token, logURL)
+		if err != nil {
+			return err
+		}
+
+		err = saveBytes(e.sink, logURL, e.obfuscator.Log(bytes))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// authenticate retrieves a token on first use and returns the same token afterwards
+func (e *Extractor) authenticate(ctx context.Context) (token string, err error) {
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.token == "" {
+		e.token, err = e.apiClient.GetToken(ctx, e.options.ClientID, e.options.ClientSecret)
+	}
+
+	return e.token, err
+}
+
+// saveObject stores the response fetched from path, with its unknown fields if they're preserved
+func (e *Extractor) saveObject(path string, object interface{}) error {
+
+	bytes, err := e.marshal(path, object)
+	if err != nil {
+		return err
+	}
+
+	return saveBytes(e.sink, path, bytes)
+}
+
+// saveList stores the list fetched from path and its pages, with their unknown fields if they're preserved
+func (e *Extractor) saveList(path string, list interface{}, items interface{}) error {
+
+	if !e.preservesRaw(path) {
+		return saveList(e.sink, path, list, items, e.options.PageSize)
+	}
+
+	bytes, err := e.marshal(path, list)
+	if err != nil {
+		return err
+	}
+
+	err = saveBytes(e.sink, path, bytes)
+	if err != nil {
+		return err
+	}
+
+	// page the items as they got stored
+	restored := struct {
+		Items []json.RawMessage `json:"items"`
+	}{}
+	err = json.Unmarshal(bytes, &restored)
+	if err != nil {
+		return err
+	}
+
+	return savePages(e.sink, path, restored.Items, e.options.PageSize)
+}
+
+// marshal returns the json to store for the response fetched from path, from its raw form if kept
+func (e *Extractor) marshal(path string, object interface{}) ([]byte, error) {
+
+	if e.options.RawResponses != nil {
+		if data, ok := e.options.RawResponses.Get(path); ok {
+			obfuscated, ok, err := obfuscateRawJSON(data, object, e.obfuscator)
+			if err != nil || ok {
+				return obfuscated, err
+			}
+			log.Warn().Msgf("Response from %v changed in more than obfuscated values, storing it as marshalled instead of raw", path)
+		}
+	}
+
+	bytes, err := json.MarshalIndent(object, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if !e.options.PreserveUnknownFields || e.options.SchemaDrift == nil {
+		return bytes, nil
+	}
+
+	return e.options.SchemaDrift.Restore(path, object, bytes, e.obfuscator.Value)
+}
+
+// preservesRaw returns true if the response fetched from path doesn't get stored as marshalled from its typed object
+func (e *Extractor) preservesRaw(path string) bool {
+
+	if e.options.PreserveUnknownFields && e.options.SchemaDrift != nil {
+		return true
+	}
+
+	if e.options.RawResponses != nil {
+		_, ok := e.options.RawResponses.Get(path)
+		return ok
+	}
+
+	return false
+}
+
+// saveObfuscatedLogs stores a response holding log lines, obfuscating them the same way as the individual logs
+func (e *Extractor) saveObfuscatedLogs(path string, object interface{}) (err error) {
+
+	bytes, err := json.MarshalIndent(object, "", "  ")
+	if err != nil {
+		return
+	}
+
+	return saveBytes(e.sink, path, e.obfuscator.Log(bytes))
+}
+
+// workGroup runs functions concurrently, limiting how many run at once, and keeps the first error they return
+// http://jmoiron.net/blog/limiting-concurrency-in-go/
+type workGroup struct {
+	semaphore chan bool
+	wait      sync.WaitGroup
+	mutex     sync.Mutex
+	err       error
+}
+
+func newWorkGroup(concurrency int) *workGroup {
+	return &workGroup{
+		semaphore: make(chan bool, concurrency),
+	}
+}
+
+func (g *workGroup) Go(f func() error) {
+
+	// try to fill semaphore up to it's full size otherwise wait for a routine to finish
+	g.semaphore <- true
+	g.wait.Add(1)
+
+	go func() {
+		// lower semaphore once the routine's finished, making room for another one to start
+		defer func() {
+			<-g.semaphore
+			g.wait.Done()
+		}()
+
+		err := f()
+		if err != nil {
+			g.mutex.Lock()
+			defer g.mutex.Unlock()
+			if g.err == nil {
+				g.err = err
+			}
+		}
+	}()
+}
+
+// Wait blocks until all functions have returned and returns the first error, if any
+func (g *workGroup) Wait() error {
+	g.wait.Wait()
+	return g.err
+}
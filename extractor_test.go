@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractorRun(t *testing.T) {
+	t.Run("StoresObfuscatedExportOfAllPipelines", func(t *testing.T) {
+
+		dataset := newFakeDataset(2, 3)
+		sink := NewMemorySink()
+		extractor := NewExtractor(NewFakeApiClient(dataset), sink, newTestObfuscator(t), ExtractorOptions{Pipelines: dataset.pipelinePaths()})
+
+		// act
+		err := extractor.Run(context.Background())
+
+		assert.Nil(t, err)
+		files := sink.Files()
+		assert.Contains(t, files, "api/pipelines/index.json")
+		assert.Contains(t, files, "api/pipelines/github.com/estafette/fake-pipeline-1/builds/1001/index.json")
+		assert.Contains(t, files, "api/pipelines/github.com/estafette/fake-pipeline-1/builds/1003/logs.stream/index.json")
+		assert.Contains(t, files, "api/pipelines/github.com/estafette/fake-pipeline-2/releases/2002/logsbyid/2002/index.json")
+		assert.Contains(t, files, "api/pipelines/github.com/estafette/fake-pipeline-2/bots/2003/alllogs/index.json")
+		assert.Contains(t, files, "api/pipelines/github.com/estafette/fake-pipeline-2/stats/releasesmemory/index.json")
+		assert.Contains(t, files, "api/builds/index.json")
+		assert.Contains(t, files, "api/catalog/entities/index.json")
+		for path, content := range files {
+			assert.NotContains(t, string(content), fakeAuthorEmail, path)
+			assert.NotContains(t, string(content), fakeLogSecret, path)
+		}
+	})
+
+	t.Run("StoresExportFromApi", func(t *testing.T) {
+
+		if testing.Short() {
+			t.Skip("skipping test in short mode, it waits for the log streams of running builds to time out")
+		}
+
+		dataset := newFakeDataset(1, 2)
+		api := newFakeAPIServer(dataset)
+		defer api.Close()
+		sink := NewMemorySink()
+		extractor := NewExtractor(NewApiClient(api.URL), sink, newTestObfuscator(t), ExtractorOptions{Pipelines: dataset.pipelinePaths()})
+
+		// act
+		err := extractor.Run(context.Background())
+
+		assert.Nil(t, err)
+		files := sink.Files()
+		assert.Contains(t, string(files["api/pipelines/github.com/estafette/fake-pipeline-1/index.json"]), obfuscatedEmail)
+		assert.Contains(t, string(files["api/pipelines/github.com/estafette/fake-pipeline-1/builds/1002/logs.stream/index.json"]), obfuscatedServiceAccount)
+		assert.Contains(t, files, "api/pipelines/github.com/estafette/fake-pipeline-1/builds/1001/logsbyid/1001/index.json")
+	})
+
+	t.Run("ReturnsErrorForUnknownPipeline", func(t *testing.T) {
+
+		extractor := NewExtractor(NewFakeApiClient(newFakeDataset(1, 1)), NewMemorySink(), newTestObfuscator(t), ExtractorOptions{Pipelines: []string{"github.com/estafette/unknown"}})
+
+		// act
+		err := extractor.Run(context.Background())
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestExtractorExtractPipeline(t *testing.T) {
+	t.Run("StoresSinglePipelineWithoutFinalizing", func(t *testing.T) {
+
+		dataset := newFakeDataset(2, 1)
+		sink := NewMemorySink()
+		extractor := NewExtractor(NewFakeApiClient(dataset), sink, newTestObfuscator(t), ExtractorOptions{})
+
+		// act
+		err := extractor.ExtractPipeline(context.Background(), "github.com/estafette/fake-pipeline-2")
+
+		assert.Nil(t, err)
+		files := sink.Files()
+		assert.Contains(t, files, "api/pipelines/github.com/estafette/fake-pipeline-2/index.json")
+		assert.NotContains(t, files, "api/pipelines/github.com/estafette/fake-pipeline-1/index.json")
+		assert.NotContains(t, files, "api/pipelines/index.json")
+	})
+}
+
+func TestWorkGroup(t *testing.T) {
+	t.Run("ReturnsFirstErrorAfterAllFunctionsReturned", func(t *testing.T) {
+
+		group := newWorkGroup(2)
+		done := make(chan bool, 3)
+
+		// act
+		group.Go(func() error { done <- true; return errors.New("first") })
+		group.Go(func() error { done <- true; return nil })
+		group.Go(func() error { done <- true; return nil })
+		err := group.Wait()
+
+		assert.NotNil(t, err)
+		assert.Equal(t, 3, len(done))
+	})
+}
+
+func newTestObfuscator(t *testing.T) *Obfuscator {
+	obfuscator, err := NewObfuscator("")
+	assert.Nil(t, err)
+	return obfuscator
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+)
+
+const (
+	fakeToken        = "fake-token"
+	fakeAuthorEmail  = "jane.doe@example.com"
+	fakeAuthorName   = "Jane Doe"
+	fakeAuthorHandle = "janedoe"
+	fakeLogSecret    = "deployer@fake-project.iam.gserviceaccount.com"
+)
+
+// fakeDataset holds the responses of a generated estafette installation by the path they're served at
+type fakeDataset struct {
+	pipelines []*contracts.Pipeline
+	responses map[string]interface{}
+	streams   map[string][]byte
+}
+
+// newFakeDataset generates pipelineCount pipelines, each with itemsPerPipeline builds, releases and bots including their logs;
+// the last build of every pipeline is running so its logs are served as event stream
+func newFakeDataset(pipelineCount, itemsPerPipeline int) *fakeDataset {
+
+	d := &fakeDataset{
+		pipelines: []*contracts.Pipeline{},
+		responses: map[string]interface{}{},
+		streams:   map[string][]byte{},
+	}
+
+	now := time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC)
+	commits := []contracts.GitCommit{{Message: "fix build", Author: contracts.GitAuthor{Email: fakeAuthorEmail, Name: fakeAuthorName, Username: fakeAuthorHandle}}}
+	organizations := []*contracts.Organization{{Name: "estafette"}}
+
+	allBuilds := []*contracts.Build{}
+	allReleases := []*contracts.Release{}
+
+	for i := 1; i <= pipelineCount; i++ {
+		repoName := fmt.Sprintf("fake-pipeline-%v", i)
+		pipelinePath := "github.com/estafette/" + repoName
+		pipelineURL := "/api/pipelines/" + pipelinePath
+
+		pipeline := &contracts.Pipeline{
+			ID:             fmt.Sprint(i),
+			RepoSource:     "github.com",
+			RepoOwner:      "estafette",
+			RepoName:       repoName,
+			RepoBranch:     "main",
+			BuildVersion:   fmt.Sprintf("1.0.%v", itemsPerPipeline),
+			BuildStatus:    contracts.StatusSucceeded,
+			Labels:         []contracts.Label{{Key: "team", Value: "estafette"}},
+			ReleaseTargets: []contracts.ReleaseTarget{{Name: "production"}},
+			Commits:        commits,
+			InsertedAt:     now,
+			UpdatedAt:      now,
+			Organizations:  organizations,
+		}
+		d.pipelines = append(d.pipelines, pipeline)
+		d.responses[pipelineURL] = pipeline
+
+		builds := PipelineBuildsListResponse{Items: []*contracts.Build{}, Pagination: contracts.Pagination{Page: 1, Size: 10, TotalPages: 1, TotalItems: itemsPerPipeline}}
+		releases := PipelineReleasesListResponse{Items: []*contracts.Release{}, Pagination: contracts.Pagination{Page: 1, Size: 10, TotalPages: 1, TotalItems: itemsPerPipeline}}
+		bots := PipelineBotsListResponse{Items: []*contracts.Bot{}, Pagination: contracts.Pagination{Page: 1, Size: 10, TotalPages: 1, TotalItems: itemsPerPipeline}}
+
+		for j := 1; j <= itemsPerPipeline; j++ {
+			id := fmt.Sprintf("%v%03d", i, j)
+			insertedAt := now.Add(time.Duration(j-itemsPerPipeline) * time.Hour)
+			logStep := &contracts.BuildLogStep{
+				Step:     "build",
+				Status:   contracts.LogStatusSucceeded,
+				LogLines: []contracts.BuildLogLine{{LineNumber: 1, Timestamp: insertedAt, StreamType: "stdout", Text: "authenticated as " + fakeLogSecret}},
+			}
+
+			status := contracts.StatusSucceeded
+			if j == itemsPerPipeline {
+				status = contracts.StatusRunning
+			}
+
+			build := &contracts.Build{ID: id, RepoSource: "github.com", RepoOwner: "estafette", RepoName: repoName, RepoBranch: "main", BuildVersion: fmt.Sprintf("1.0.%v", j), BuildStatus: status, Commits: commits, InsertedAt: insertedAt}
+			builds.Items = append(builds.Items, build)
+			buildURL := fmt.Sprintf("%v/builds/%v", pipelineURL, id)
+			d.responses[buildURL] = build
+			d.responses[buildURL+"/warnings"] = PipelineWarningsResponse{Warnings: []*contracts.Warning{}}
+			d.responses[buildURL+"/alllogs"] = PipelineBuildsLogsListResponse{Items: []*contracts.BuildLog{{ID: id, RepoSource: "github.com", RepoOwner: "estafette", RepoName: repoName, BuildID: id, Steps: []*contracts.BuildLogStep{logStep}, InsertedAt: insertedAt}}}
+			if status == contracts.StatusRunning {
+				d.streams[buildURL+"/logs.stream"] = []byte(fmt.Sprintf("event:log\ndata:{\"step\":\"build\",\"logLine\":{\"text\":\"authenticated as %v\"}}\n\n", fakeLogSecret))
+			} else {
+				d.responses[fmt.Sprintf("%v/logsbyid/%v", buildURL, id)] = d.responses[buildURL+"/alllogs"].(PipelineBuildsLogsListResponse).Items[0]
+			}
+
+			release := &contracts.Release{ID: id, Name: "production", RepoSource: "github.com", RepoOwner: "estafette", RepoName: repoName, ReleaseVersion: fmt.Sprintf("1.0.%v", j), ReleaseStatus: contracts.StatusSucceeded, InsertedAt: &insertedAt}
+			releases.Items = append(releases.Items, release)
+			releaseURL := fmt.Sprintf("%v/releases/%v", pipelineURL, id)
+			d.responses[releaseURL] = release
+			d.responses[releaseURL+"/alllogs"] = PipelineReleasesLogsListResponse{Items: []*contracts.ReleaseLog{{ID: id, RepoSource: "github.com", RepoOwner: "estafette", RepoName: repoName, ReleaseID: id, Steps: []*contracts.BuildLogStep{logStep}, InsertedAt: insertedAt}}}
+			d.responses[fmt.Sprintf("%v/logsbyid/%v", releaseURL, id)] = d.responses[releaseURL+"/alllogs"].(PipelineReleasesLogsListResponse).Items[0]
+
+			bot := &contracts.Bot{ID: id, Name: "cleanup", RepoSource: "github.com", RepoOwner: "estafette", RepoName: repoName, BotStatus: contracts.StatusSucceeded, InsertedAt: &insertedAt}
+			bots.Items = append(bots.Items, bot)
+			botURL := fmt.Sprintf("%v/bots/%v", pipelineURL, id)
+			d.responses[botURL] = bot
+			d.responses[botURL+"/alllogs"] = PipelineBotsLogsListResponse{Items: []*contracts.BotLog{{ID: id, RepoSource: "github.com", RepoOwner: "estafette", RepoName: repoName, BotID: id, Steps: []*contracts.BuildLogStep{logStep}, InsertedAt: insertedAt}}}
+			d.responses[fmt.Sprintf("%v/logsbyid/%v", botURL, id)] = d.responses[botURL+"/alllogs"].(PipelineBotsLogsListResponse).Items[0]
+		}
+
+		d.responses[pipelineURL+"/builds"] = builds
+		d.responses[pipelineURL+"/releases"] = releases
+		d.responses[pipelineURL+"/bots"] = bots
+		allBuilds = append(allBuilds, builds.Items...)
+		allReleases = append(allReleases, releases.Items...)
+
+		d.responses[pipelineURL+"/buildbranches"] = PipelineBuildBranchesListResponse{Items: []*NameCount{{Name: "main", Count: itemsPerPipeline}}, Pagination: singlePage(1)}
+		d.responses[pipelineURL+"/botnames"] = PipelineBotNamesListResponse{Items: []*NameCount{{Name: "cleanup", Count: itemsPerPipeline}}, Pagination: singlePage(1)}
+		d.responses[pipelineURL+"/warnings"] = PipelineWarningsResponse{Warnings: []*contracts.Warning{{Status: "warning", Message: "builds as " + fakeLogSecret}}}
+		for _, stats := range []string{"builds", "releases"} {
+			d.responses[fmt.Sprintf("%v/stats/%vdurations", pipelineURL, stats)] = PipelineDurationsResponse{Durations: []*DurationMeasurement{{InsertedAt: now, Duration: 90 * time.Second}}}
+			cpu, memory := 0.5, 256.0
+			d.responses[fmt.Sprintf("%v/stats/%vcpu", pipelineURL, stats)] = PipelineMeasurementsResponse{Measurements: []*ResourceMeasurement{{InsertedAt: now, MaxCPUUsage: &cpu}}}
+			d.responses[fmt.Sprintf("%v/stats/%vmemory", pipelineURL, stats)] = PipelineMeasurementsResponse{Measurements: []*ResourceMeasurement{{InsertedAt: now, MaxMemoryUsage: &memory}}}
+		}
+	}
+
+	d.responses["/api/pipelines"] = PipelinesListResponse{Items: d.pipelines, Pagination: singlePage(len(d.pipelines))}
+	d.responses["/api/builds"] = aggregateBuilds(allBuilds)
+	d.responses["/api/releases"] = aggregateReleases(allReleases)
+
+	mostBuilds := PipelineCountsListResponse{Items: []*PipelineCount{}, Pagination: singlePage(len(d.pipelines))}
+	entities := CatalogEntitiesListResponse{Items: []*contracts.CatalogEntity{{Key: "organization", Value: "estafette"}}, Pagination: singlePage(len(d.pipelines) + 1)}
+	for _, p := range d.pipelines {
+		mostBuilds.Items = append(mostBuilds.Items, &PipelineCount{RepoSource: p.RepoSource, RepoOwner: p.RepoOwner, RepoName: p.RepoName, NrRecords: itemsPerPipeline})
+		entities.Items = append(entities.Items, &contracts.CatalogEntity{ParentKey: "organization", ParentValue: "estafette", Key: "service", Value: p.RepoName, LinkedPipeline: p.GetFullRepoPath()})
+	}
+	d.responses["/api/stats/mostbuilds"] = mostBuilds
+	d.responses["/api/stats/mostreleases"] = mostBuilds
+	d.responses["/api/catalog/entities"] = entities
+	d.responses["/api/catalog/filters"] = []string{"organization", "service"}
+	d.responses["/api/labels"] = LabelsListResponse{Items: []*contracts.Label{{Key: "team", Value: "estafette"}}, Pagination: singlePage(1)}
+	d.responses["/api/labels/frequent"] = FrequentLabelsListResponse{Items: []*LabelCount{{Key: "team", Value: "estafette", PipelinesCount: pipelineCount}}, Pagination: singlePage(1)}
+	d.responses["/api/releasetargets"] = ReleaseTargetsListResponse{Items: []*ReleaseTargetCount{{Name: "production", PipelinesCount: pipelineCount}}, Pagination: singlePage(1)}
+	d.responses["/api/organizations"] = OrganizationsListResponse{Items: organizations, Pagination: singlePage(1)}
+
+	return d
+}
+
+// pipelinePaths returns the source/owner/name paths of all generated pipelines, as passed to --pipelines
+func (d *fakeDataset) pipelinePaths() (paths []string) {
+	for _, p := range d.pipelines {
+		paths = append(paths, p.GetFullRepoPath())
+	}
+	return
+}
+
+// lookup returns the response served at path, ignoring the query parameters
+func (d *fakeDataset) lookup(path string) (response interface{}, err error) {
+	path = strings.SplitN(path, "?", 2)[0]
+	response, ok := d.responses[path]
+	if !ok {
+		return nil, fmt.Errorf("%v responded with status code %v", path, http.StatusNotFound)
+	}
+	return response, nil
+}
+
+// NewFakeApiClient returns an ApiClient serving the dataset without any network traffic
+func NewFakeApiClient(dataset *fakeDataset) ApiClient {
+	return &fakeApiClient{
+		dataset: dataset,
+	}
+}
+
+type fakeApiClient struct {
+	dataset *fakeDataset
+}
+
+func (c *fakeApiClient) GetToken(ctx context.Context, clientID, clientSecret string) (token string, err error) {
+	return fakeToken, nil
+}
+
+func (c *fakeApiClient) GetPipelines(ctx context.Context, token string, pageNumber, pageSize int, filters map[string][]string) (response PipelinesListResponse, err error) {
+	err = c.getJSON("/api/pipelines", &response)
+	return
+}
+
+func (c *fakeApiClient) GetPipeline(ctx context.Context, token string, pipelinePath string) (pipeline *contracts.Pipeline, err error) {
+	err = c.getJSON("/api/pipelines/"+pipelinePath, &pipeline)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineBuilds(ctx context.Context, token string, pipelinePath string) (response PipelineBuildsListResponse, err error) {
+	err = c.getJSON(fmt.Sprintf("/api/pipelines/%v/builds", pipelinePath), &response)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineBuild(ctx context.Context, token string, pipelineBuildPath string) (build *contracts.Build, err error) {
+	err = c.getJSON(pipelineBuildPath, &build)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineBuildLogs(ctx context.Context, token string, pipelineBuildPath string) (buildLogs PipelineBuildsLogsListResponse, err error) {
+	err = c.getJSON(pipelineBuildPath, &buildLogs)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineReleases(ctx context.Context, token string, pipelinePath string) (response PipelineReleasesListResponse, err error) {
+	err = c.getJSON(fmt.Sprintf("/api/pipelines/%v/releases", pipelinePath), &response)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineRelease(ctx context.Context, token string, pipelineReleasePath string) (release *contracts.Release, err error) {
+	err = c.getJSON(pipelineReleasePath, &release)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineReleaseLogs(ctx context.Context, token string, pipelineReleasePath string) (releaseLogs PipelineReleasesLogsListResponse, err error) {
+	err = c.getJSON(pipelineReleasePath, &releaseLogs)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineBots(ctx context.Context, token string, pipelinePath string) (response PipelineBotsListResponse, err error) {
+	err = c.getJSON(fmt.Sprintf("/api/pipelines/%v/bots", pipelinePath), &response)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineBot(ctx context.Context, token string, pipelineBotPath string) (bot *contracts.Bot, err error) {
+	err = c.getJSON(pipelineBotPath, &bot)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineBotLogs(ctx context.Context, token string, pipelineBotPath string) (botLogs PipelineBotsLogsListResponse, err error) {
+	err = c.getJSON(pipelineBotPath, &botLogs)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineBuildBranches(ctx context.Context, token string, pipelinePath string) (response PipelineBuildBranchesListResponse, err error) {
+	err = c.getJSON(fmt.Sprintf("/api/pipelines/%v/buildbranches", pipelinePath), &response)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineBotNames(ctx context.Context, token string, pipelinePath string) (response PipelineBotNamesListResponse, err error) {
+	err = c.getJSON(fmt.Sprintf("/api/pipelines/%v/botnames", pipelinePath), &response)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineWarnings(ctx context.Context, token string, pipelinePath string) (response PipelineWarningsResponse, err error) {
+	err = c.getJSON(fmt.Sprintf("/api/pipelines/%v/warnings", pipelinePath), &response)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineStatsBuildsDurations(ctx context.Context, token string, pipelinePath string) (response PipelineDurationsResponse, err error) {
+	err = c.getJSON(fmt.Sprintf("/api/pipelines/%v/stats/buildsdurations", pipelinePath), &response)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineStatsBuildsCPU(ctx context.Context, token string, pipelinePath string) (response PipelineMeasurementsResponse, err error) {
+	err = c.getJSON(fmt.Sprintf("/api/pipelines/%v/stats/buildscpu", pipelinePath), &response)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineStatsBuildsMemory(ctx context.Context, token string, pipelinePath string) (response PipelineMeasurementsResponse, err error) {
+	err = c.getJSON(fmt.Sprintf("/api/pipelines/%v/stats/buildsmemory", pipelinePath), &response)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineStatsReleasesDurations(ctx context.Context, token string, pipelinePath string) (response PipelineDurationsResponse, err error) {
+	err = c.getJSON(fmt.Sprintf("/api/pipelines/%v/stats/releasesdurations", pipelinePath), &response)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineStatsReleasesCPU(ctx context.Context, token string, pipelinePath string) (response PipelineMeasurementsResponse, err error) {
+	err = c.getJSON(fmt.Sprintf("/api/pipelines/%v/stats/releasescpu", pipelinePath), &response)
+	return
+}
+
+func (c *fakeApiClient) GetPipelineStatsReleasesMemory(ctx context.Context, token string, pipelinePath string) (response PipelineMeasurementsResponse, err error) {
+	err = c.getJSON(fmt.Sprintf("/api/pipelines/%v/stats/releasesmemory", pipelinePath), &response)
+	return
+}
+
+func (c *fakeApiClient) GetCatalogEntities(ctx context.Context, token string, pageNumber, pageSize int, filters map[string][]string) (response CatalogEntitiesListResponse, err error) {
+	err = c.getJSON("/api/catalog/entities", &response)
+	return
+}
+
+func (c *fakeApiClient) GetCatalogFilters(ctx context.Context, token string) (filters []string, err error) {
+	err = c.getJSON("/api/catalog/filters", &filters)
+	return
+}
+
+func (c *fakeApiClient) GetLabels(ctx context.Context, token string, pageNumber, pageSize int) (response LabelsListResponse, err error) {
+	err = c.getJSON("/api/labels", &response)
+	return
+}
+
+func (c *fakeApiClient) GetFrequentLabels(ctx context.Context, token string, pageNumber, pageSize int) (response FrequentLabelsListResponse, err error) {
+	err = c.getJSON("/api/labels/frequent", &response)
+	return
+}
+
+func (c *fakeApiClient) GetStatsMostBuilds(ctx context.Context, token string, pageNumber, pageSize int, filters map[string][]string) (response PipelineCountsListResponse, err error) {
+	err = c.getJSON("/api/stats/mostbuilds", &response)
+	return
+}
+
+func (c *fakeApiClient) GetStatsMostReleases(ctx context.Context, token string, pageNumber, pageSize int, filters map[string][]string) (response PipelineCountsListResponse, err error) {
+	err = c.getJSON("/api/stats/mostreleases", &response)
+	return
+}
+
+func (c *fakeApiClient) GetReleaseTargets(ctx context.Context, token string, pageNumber, pageSize int) (response ReleaseTargetsListResponse, err error) {
+	err = c.getJSON("/api/releasetargets", &response)
+	return
+}
+
+func (c *fakeApiClient) GetOrganizations(ctx context.Context, token string, pageNumber, pageSize int) (response OrganizationsListResponse, err error) {
+	err = c.getJSON("/api/organizations", &response)
+	return
+}
+
+func (c *fakeApiClient) GetBytesResponse(ctx context.Context, token string, path string) (bytes []byte, err error) {
+	response, err := c.dataset.lookup(path)
+	if err != nil {
+		return
+	}
+	return json.Marshal(response)
+}
+
+func (c *fakeApiClient) GetSSEResponse(ctx context.Context, token string, path string, maxNumberOfEvents int) (bytes []byte, err error) {
+	return c.dataset.streams[path], nil
+}
+
+// getJSON round-trips the response through json, so callers can't modify the dataset and get what the real client would decode
+func (c *fakeApiClient) getJSON(path string, target interface{}) error {
+	bytes, err := c.GetBytesResponse(context.Background(), fakeToken, path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, target)
+}
+
+// newFakeAPIServer returns a server acting as the estafette api for the dataset, requiring the token handed out by its login endpoint
+func newFakeAPIServer(dataset *fakeDataset) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Method == http.MethodPost && r.URL.Path == "/api/auth/client/login" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"token":%q}`, fakeToken)
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+fakeToken {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		if stream, ok := dataset.streams[r.URL.Path]; ok {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write(stream)
+			return
+		}
+
+		response, err := dataset.lookup(r.URL.Path)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+}
@@ -0,0 +1,991 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultGeneratorProfile = "small"
+
+	// defaultGenerateListLimit caps the lists across pipelines, like the extractor caps the lists it fetches
+	defaultGenerateListLimit = 1000
+
+	// generatedAPIHost is recorded in the manifest of a generated dataset instead of the host of an api
+	generatedAPIHost = "generated"
+
+	// firstGeneratedID is the id of the first generated build, release, bot or log, ids increase from there
+	firstGeneratedID = 1000
+)
+
+// generatorProfiles are the sizes of generated datasets by name, for demos and for testing the web ui at scale
+var generatorProfiles = map[string]GeneratorOptions{
+	"small":   {Pipelines: 10, BuildsPerPipeline: 10, ReleasesPerTarget: 3, BotsPerPipeline: 2},
+	"wide":    {Pipelines: 5000, BuildsPerPipeline: 10, ReleasesPerTarget: 3, BotsPerPipeline: 2},
+	"deep":    {Pipelines: 10, BuildsPerPipeline: 10000, ReleasesPerTarget: 100, BotsPerPipeline: 50},
+	"verbose": {Pipelines: 10, BuildsPerPipeline: 10, ReleasesPerTarget: 3, BotsPerPipeline: 2, LogLinesPerStep: 5000},
+}
+
+var (
+	generatedSource       = "github.com"
+	generatedOwners       = []string{"acme", "globex", "initech"}
+	generatedDomains      = []string{"billing", "checkout", "catalog", "search", "payments", "inventory", "shipping", "identity", "notifications", "analytics", "reviews", "pricing"}
+	generatedKinds        = []string{"api", "web", "worker", "gateway", "sync", "importer", "exporter", "scheduler"}
+	generatedTeams        = []string{"team-orange", "team-blue", "team-green", "team-purple"}
+	generatedLanguages    = []string{"golang", "nodejs"}
+	generatedTargets      = []string{"development", "staging", "production"}
+	generatedBotNames     = []string{"cleanup", "dependency-updates", "stale-branches"}
+	generatedBranches     = []string{"feature/new-endpoint", "fix/flaky-test", "chore/bump-dependencies"}
+	generatedCommitVerbs  = []string{"add", "fix", "refactor", "remove", "update", "document"}
+	generatedCommitThings = []string{"retry on timeout", "pagination", "health check", "metrics", "validation", "caching", "logging", "config loading"}
+	generatedAuthors      = []contracts.GitAuthor{
+		{Email: "alex@example.com", Name: "Alex Example", Username: "alexexample"},
+		{Email: "sam@example.com", Name: "Sam Sample", Username: "samsample"},
+		{Email: "kim@example.com", Name: "Kim Demo", Username: "kimdemo"},
+		{Email: "robin@example.com", Name: "Robin Test", Username: "robintest"},
+	}
+)
+
+// GeneratorOptions configures the size and shape of a generated dataset
+type GeneratorOptions struct {
+	// Profile names the entry in generatorProfiles the sizes that aren't set are taken from, defaults to small
+	Profile string
+
+	// Seed makes the generated dataset reproducible, the same seed and reference time generate the same dataset
+	Seed int64
+
+	// ReferenceTime is the time the most recent builds, releases and bots happen at, defaults to the start of the current day
+	ReferenceTime time.Time
+
+	// Pipelines is the number of pipelines to generate
+	Pipelines int
+
+	// BuildsPerPipeline is the number of builds to generate for every pipeline
+	BuildsPerPipeline int
+
+	// ReleasesPerTarget is the number of releases to generate for every release target of a pipeline
+	ReleasesPerTarget int
+
+	// BotsPerPipeline is the number of bot runs to generate for every pipeline
+	BotsPerPipeline int
+
+	// LogLinesPerStep pads the log of every step that runs to this number of lines, 0 keeps the lines of the step type
+	LogLinesPerStep int
+
+	// ListLimit caps the builds and releases lists across pipelines to their most recent items, defaults to 1000;
+	// every other file only depends on a single pipeline, so memory doesn't grow with the number of pipelines
+	ListLimit int
+
+	// PageSize splits generated lists into pages of this size next to the full list, 0 disables paging
+	PageSize int
+}
+
+// withDefaults returns the options with the sizes that aren't set taken from their profile
+func (o GeneratorOptions) withDefaults() GeneratorOptions {
+
+	profile, ok := generatorProfiles[o.Profile]
+	if !ok {
+		o.Profile = defaultGeneratorProfile
+		profile = generatorProfiles[defaultGeneratorProfile]
+	}
+
+	if o.ReferenceTime.IsZero() {
+		o.ReferenceTime = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	if o.Pipelines <= 0 {
+		o.Pipelines = profile.Pipelines
+	}
+	if o.BuildsPerPipeline <= 0 {
+		o.BuildsPerPipeline = profile.BuildsPerPipeline
+	}
+	if o.ReleasesPerTarget <= 0 {
+		o.ReleasesPerTarget = profile.ReleasesPerTarget
+	}
+	if o.BotsPerPipeline <= 0 {
+		o.BotsPerPipeline = profile.BotsPerPipeline
+	}
+	if o.LogLinesPerStep <= 0 {
+		o.LogLinesPerStep = profile.LogLinesPerStep
+	}
+	if o.ListLimit <= 0 {
+		o.ListLimit = defaultGenerateListLimit
+	}
+
+	return o
+}
+
+// generatorProfileNames returns the names of the generator profiles in alphabetical order
+func generatorProfileNames() []string {
+	return sortedKeys(generatorProfiles)
+}
+
+// Generator fabricates a coherent dataset without any api and streams it into a sink in the same layout as an export
+type Generator struct {
+	sink    FixtureSink
+	options GeneratorOptions
+
+	pipelinePaths []string
+	nextID        int
+	pipelines     []*contracts.Pipeline
+	releaseCounts map[string]int
+
+	// the most recent builds and releases across pipelines, trimmed to the list limit as they grow
+	builds   []*contracts.Build
+	releases []*contracts.Release
+}
+
+// NewGenerator returns a Generator storing into sink
+func NewGenerator(sink FixtureSink, options GeneratorOptions) *Generator {
+
+	options = options.withDefaults()
+
+	return &Generator{
+		sink:          sink,
+		options:       options,
+		pipelinePaths: generatedPipelinePaths(options.Seed, options.Pipelines),
+		nextID:        firstGeneratedID,
+		releaseCounts: map[string]int{},
+	}
+}
+
+// generatedPipelinePaths returns the source/owner/name paths of count pipelines with unique names picked using seed
+func generatedPipelinePaths(seed int64, count int) []string {
+
+	random := rand.New(rand.NewSource(seed))
+	names := map[string]bool{}
+	paths := []string{}
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("%v-%v", pick(random, generatedDomains), pick(random, generatedKinds))
+		for suffix := 2; names[name]; suffix++ {
+			name = fmt.Sprintf("%v-%v-%v", pick(random, generatedDomains), pick(random, generatedKinds), suffix)
+		}
+		names[name] = true
+		paths = append(paths, fmt.Sprintf("%v/%v/%v", generatedSource, pick(random, generatedOwners), name))
+	}
+
+	return paths
+}
+
+// PipelinePaths returns the source/owner/name paths of the pipelines the generator creates
+func (g *Generator) PipelinePaths() []string {
+	return g.pipelinePaths
+}
+
+// Run generates all pipelines, followed by the lists across them and the catalog and insights, and finalizes the sink
+func (g *Generator) Run(ctx context.Context) (err error) {
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Generate")
+	defer span.Finish()
+
+	for i, path := range g.pipelinePaths {
+		err = g.generatePipeline(ctx, i, path)
+		if err != nil {
+			return
+		}
+	}
+
+	pipelines := PipelinesListResponse{
+		Items:      g.pipelines,
+		Pagination: singlePage(len(g.pipelines)),
+	}
+	err = saveList(g.sink, "/api/pipelines", pipelines, pipelines.Items, g.options.PageSize)
+	if err != nil {
+		return
+	}
+
+	builds := PipelineBuildsListResponse{Items: mostRecentBuilds(g.builds, g.options.ListLimit)}
+	builds.Pagination = singlePage(len(builds.Items))
+	err = saveList(g.sink, "/api/builds", builds, builds.Items, g.options.PageSize)
+	if err != nil {
+		return
+	}
+
+	releases := PipelineReleasesListResponse{Items: mostRecentReleases(g.releases, g.options.ListLimit)}
+	releases.Pagination = singlePage(len(releases.Items))
+	err = saveList(g.sink, "/api/releases", releases, releases.Items, g.options.PageSize)
+	if err != nil {
+		return
+	}
+
+	err = g.generateGlobals(ctx)
+	if err != nil {
+		return
+	}
+
+	return g.sink.Finalize()
+}
+
+// generatePipeline stores pipeline number i with its builds, releases, bots, logs and stats; logs are stored as soon as they're
+// synthesized, so only the runs of a single pipeline are kept in memory
+func (g *Generator) generatePipeline(ctx context.Context, i int, path string) (err error) {
+
+	span, _ := opentracing.StartSpanFromContext(ctx, "GeneratePipeline")
+	defer span.Finish()
+	span.SetTag("pipeline", path)
+
+	// every pipeline gets its own source of randomness, so a pipeline doesn't change when others get added
+	random := rand.New(rand.NewSource(g.options.Seed*1000003 + int64(i)))
+	url := "/api/pipelines/" + path
+	segments := strings.Split(path, "/")
+
+	pipeline := &contracts.Pipeline{
+		ID:         fmt.Sprint(i + 1),
+		RepoSource: segments[0],
+		RepoOwner:  segments[1],
+		RepoName:   segments[2],
+		RepoBranch: "main",
+		Labels: []contracts.Label{
+			{Key: "app", Value: segments[2]},
+			{Key: "team", Value: pick(random, generatedTeams)},
+			{Key: "language", Value: pick(random, generatedLanguages)},
+		},
+		Organizations: []*contracts.Organization{{Name: segments[1]}},
+	}
+
+	// every pipeline releases to development, most of them to staging and production as well
+	targets := generatedTargets[:1+random.Intn(len(generatedTargets))]
+	stages := generatedBuildStages(random, labelValue(pipeline.Labels, "language"))
+	pipeline.Manifest = generatedManifest(pipeline, stages, targets)
+
+	synthesizer := NewLogSynthesizer(random, g.options.LogLinesPerStep)
+
+	// builds, oldest first
+	builds := []*contracts.Build{}
+	start := g.options.ReferenceTime.Add(-time.Duration(g.options.BuildsPerPipeline) * 4 * time.Hour)
+	for j := 0; j < g.options.BuildsPerPipeline; j++ {
+		insertedAt := start.Add(time.Duration(j)*4*time.Hour + time.Duration(random.Intn(180))*time.Minute)
+		build := g.generateBuild(random, pipeline, j, j == g.options.BuildsPerPipeline-1, insertedAt)
+		err = g.saveBuildLogs(url, build, synthesizeBuildLog(synthesizer, pipeline, build, stages))
+		if err != nil {
+			return
+		}
+		builds = append(builds, build)
+	}
+
+	// releases per target of the most recent succeeded builds, oldest first
+	releases := []*contracts.Release{}
+	for _, target := range targets {
+		releasesForTarget := []*contracts.Release{}
+		for j := len(builds) - 1; j >= 0 && len(releasesForTarget) < g.options.ReleasesPerTarget; j-- {
+			if builds[j].BuildStatus == contracts.StatusSucceeded && builds[j].RepoBranch == pipeline.RepoBranch {
+				release := g.generateRelease(random, pipeline, builds[j], target)
+				err = g.saveReleaseLogs(url, release, synthesizeReleaseLog(synthesizer, pipeline, release))
+				if err != nil {
+					return
+				}
+				releasesForTarget = append(releasesForTarget, release)
+			}
+		}
+		for j := len(releasesForTarget) - 1; j >= 0; j-- {
+			releases = append(releases, releasesForTarget[j])
+		}
+
+		releaseTarget := contracts.ReleaseTarget{Name: target}
+		if len(releasesForTarget) > 0 {
+			releaseTarget.ActiveReleases = []contracts.Release{*releasesForTarget[0]}
+		}
+		pipeline.ReleaseTargets = append(pipeline.ReleaseTargets, releaseTarget)
+	}
+
+	bots := []*contracts.Bot{}
+	for j := 0; j < g.options.BotsPerPipeline; j++ {
+		insertedAt := g.options.ReferenceTime.Add(-time.Duration(g.options.BotsPerPipeline-j) * 24 * time.Hour)
+		bot := g.generateBot(random, pipeline, generatedBotNames[j%len(generatedBotNames)], insertedAt)
+		err = g.saveBotLogs(url, bot, synthesizeBotLog(synthesizer, pipeline, bot))
+		if err != nil {
+			return
+		}
+		bots = append(bots, bot)
+	}
+
+	// the pipeline reflects its most recent build
+	latest := builds[len(builds)-1]
+	pipeline.RepoRevision = latest.RepoRevision
+	pipeline.BuildVersion = latest.BuildVersion
+	pipeline.BuildStatus = latest.BuildStatus
+	pipeline.Commits = latest.Commits
+	pipeline.InsertedAt = latest.InsertedAt
+	pipeline.StartedAt = latest.StartedAt
+	pipeline.UpdatedAt = latest.UpdatedAt
+	pipeline.LastUpdatedAt = latest.UpdatedAt
+	pipeline.Duration = latest.Duration
+	pipeline.RecentCommitters = recentCommitters(builds)
+
+	for _, b := range builds {
+		b.Labels = pipeline.Labels
+		b.ReleaseTargets = pipeline.ReleaseTargets
+	}
+
+	err = saveObject(g.sink, url, pipeline)
+	if err != nil {
+		return
+	}
+
+	// lists are sorted most recent first, like the api does
+	buildsList := PipelineBuildsListResponse{Items: reverseBuilds(builds), Pagination: singlePage(len(builds))}
+	err = saveList(g.sink, url+"/builds", buildsList, buildsList.Items, g.options.PageSize)
+	if err != nil {
+		return
+	}
+
+	releasesList := aggregateReleases(releases)
+	err = saveList(g.sink, url+"/releases", releasesList, releasesList.Items, g.options.PageSize)
+	if err != nil {
+		return
+	}
+
+	botsList := PipelineBotsListResponse{Items: reverseBots(bots), Pagination: singlePage(len(bots))}
+	err = saveList(g.sink, url+"/bots", botsList, botsList.Items, g.options.PageSize)
+	if err != nil {
+		return
+	}
+
+	for _, b := range builds {
+		err = g.saveBuild(url, b)
+		if err != nil {
+			return
+		}
+	}
+	for _, r := range releases {
+		err = g.saveRelease(url, r)
+		if err != nil {
+			return
+		}
+	}
+	for _, b := range bots {
+		err = g.saveBot(url, b)
+		if err != nil {
+			return
+		}
+	}
+
+	err = g.savePipelineSubResources(url, random, pipeline, builds, releases, bots)
+	if err != nil {
+		return
+	}
+
+	g.pipelines = append(g.pipelines, pipeline)
+	g.releaseCounts[path] = len(releases)
+	g.builds = append(g.builds, builds...)
+	if len(g.builds) > 2*g.options.ListLimit {
+		g.builds = mostRecentBuilds(g.builds, g.options.ListLimit)
+	}
+	g.releases = append(g.releases, releases...)
+	if len(g.releases) > 2*g.options.ListLimit {
+		g.releases = mostRecentReleases(g.releases, g.options.ListLimit)
+	}
+
+	return nil
+}
+
+// generateBuild returns build number j of pipeline; the last build is still running
+func (g *Generator) generateBuild(random *rand.Rand, pipeline *contracts.Pipeline, j int, last bool, insertedAt time.Time) *contracts.Build {
+
+	branch := pipeline.RepoBranch
+	if random.Intn(5) == 0 {
+		branch = pick(random, generatedBranches)
+	}
+
+	status := contracts.StatusSucceeded
+	switch {
+	case last:
+		status = contracts.StatusRunning
+	case random.Intn(8) == 0:
+		status = contracts.StatusFailed
+	}
+
+	startedAt := insertedAt.Add(time.Duration(1+random.Intn(20)) * time.Second)
+	pendingDuration := startedAt.Sub(insertedAt)
+
+	build := &contracts.Build{
+		ID:              g.newID(),
+		RepoSource:      pipeline.RepoSource,
+		RepoOwner:       pipeline.RepoOwner,
+		RepoName:        pipeline.RepoName,
+		RepoBranch:      branch,
+		RepoRevision:    randomRevision(random),
+		BuildVersion:    fmt.Sprintf("1.%v.%v", j/20, j),
+		BuildStatus:     status,
+		Manifest:        pipeline.Manifest,
+		Commits:         randomCommits(random),
+		InsertedAt:      insertedAt,
+		StartedAt:       &startedAt,
+		UpdatedAt:       startedAt,
+		PendingDuration: &pendingDuration,
+		Organizations:   pipeline.Organizations,
+	}
+	if branch != pipeline.RepoBranch {
+		build.BuildVersion += "-" + strings.ReplaceAll(branch, "/", "-")
+	}
+
+	return build
+}
+
+// generateRelease returns a release of build to target
+func (g *Generator) generateRelease(random *rand.Rand, pipeline *contracts.Pipeline, build *contracts.Build, target string) *contracts.Release {
+
+	insertedAt := build.UpdatedAt.Add(time.Duration(5+random.Intn(120)) * time.Minute)
+	startedAt := insertedAt.Add(time.Duration(1+random.Intn(10)) * time.Second)
+	pendingDuration := startedAt.Sub(insertedAt)
+
+	status := contracts.StatusSucceeded
+	if random.Intn(10) == 0 {
+		status = contracts.StatusFailed
+	}
+
+	return &contracts.Release{
+		ID:              g.newID(),
+		Name:            target,
+		RepoSource:      pipeline.RepoSource,
+		RepoOwner:       pipeline.RepoOwner,
+		RepoName:        pipeline.RepoName,
+		ReleaseVersion:  build.BuildVersion,
+		ReleaseStatus:   status,
+		InsertedAt:      &insertedAt,
+		StartedAt:       &startedAt,
+		PendingDuration: &pendingDuration,
+		Organizations:   pipeline.Organizations,
+	}
+}
+
+// generateBot returns a run of bot name
+func (g *Generator) generateBot(random *rand.Rand, pipeline *contracts.Pipeline, name string, insertedAt time.Time) *contracts.Bot {
+
+	startedAt := insertedAt.Add(time.Duration(1+random.Intn(10)) * time.Second)
+	pendingDuration := startedAt.Sub(insertedAt)
+
+	status := contracts.StatusSucceeded
+	if random.Intn(10) == 0 {
+		status = contracts.StatusFailed
+	}
+
+	return &contracts.Bot{
+		ID:              g.newID(),
+		Name:            name,
+		RepoSource:      pipeline.RepoSource,
+		RepoOwner:       pipeline.RepoOwner,
+		RepoName:        pipeline.RepoName,
+		BotStatus:       status,
+		InsertedAt:      &insertedAt,
+		StartedAt:       &startedAt,
+		PendingDuration: &pendingDuration,
+		Organizations:   pipeline.Organizations,
+	}
+}
+
+// saveBuild stores a build with its warnings
+func (g *Generator) saveBuild(pipelineURL string, build *contracts.Build) (err error) {
+
+	url := fmt.Sprintf("%v/builds/%v", pipelineURL, build.ID)
+
+	err = saveObject(g.sink, url, build)
+	if err != nil {
+		return
+	}
+
+	return saveObject(g.sink, url+"/warnings", PipelineWarningsResponse{Warnings: []*contracts.Warning{}})
+}
+
+// saveBuildLogs stores the logs of a build
+func (g *Generator) saveBuildLogs(pipelineURL string, build *contracts.Build, steps []*contracts.BuildLogStep) (err error) {
+
+	url := fmt.Sprintf("%v/builds/%v", pipelineURL, build.ID)
+
+	buildLog := &contracts.BuildLog{
+		ID:           g.newID(),
+		RepoSource:   build.RepoSource,
+		RepoOwner:    build.RepoOwner,
+		RepoName:     build.RepoName,
+		RepoBranch:   build.RepoBranch,
+		RepoRevision: build.RepoRevision,
+		BuildID:      build.ID,
+		Steps:        steps,
+		InsertedAt:   build.InsertedAt,
+	}
+
+	err = saveObject(g.sink, url+"/alllogs", PipelineBuildsLogsListResponse{Items: []*contracts.BuildLog{buildLog}})
+	if err != nil {
+		return
+	}
+
+	return g.saveLogs(url, build.BuildStatus, buildLog.ID, buildLog, steps)
+}
+
+// saveRelease stores a release
+func (g *Generator) saveRelease(pipelineURL string, release *contracts.Release) error {
+	return saveObject(g.sink, fmt.Sprintf("%v/releases/%v", pipelineURL, release.ID), release)
+}
+
+// saveReleaseLogs stores the logs of a release
+func (g *Generator) saveReleaseLogs(pipelineURL string, release *contracts.Release, steps []*contracts.BuildLogStep) (err error) {
+
+	url := fmt.Sprintf("%v/releases/%v", pipelineURL, release.ID)
+
+	releaseLog := &contracts.ReleaseLog{
+		ID:         g.newID(),
+		RepoSource: release.RepoSource,
+		RepoOwner:  release.RepoOwner,
+		RepoName:   release.RepoName,
+		ReleaseID:  release.ID,
+		Steps:      steps,
+		InsertedAt: *release.InsertedAt,
+	}
+
+	err = saveObject(g.sink, url+"/alllogs", PipelineReleasesLogsListResponse{Items: []*contracts.ReleaseLog{releaseLog}})
+	if err != nil {
+		return
+	}
+
+	return g.saveLogs(url, release.ReleaseStatus, releaseLog.ID, releaseLog, steps)
+}
+
+// saveBot stores a bot run
+func (g *Generator) saveBot(pipelineURL string, bot *contracts.Bot) error {
+	return saveObject(g.sink, fmt.Sprintf("%v/bots/%v", pipelineURL, bot.ID), bot)
+}
+
+// saveBotLogs stores the logs of a bot run
+func (g *Generator) saveBotLogs(pipelineURL string, bot *contracts.Bot, steps []*contracts.BuildLogStep) (err error) {
+
+	url := fmt.Sprintf("%v/bots/%v", pipelineURL, bot.ID)
+
+	botLog := &contracts.BotLog{
+		ID:         g.newID(),
+		RepoSource: bot.RepoSource,
+		RepoOwner:  bot.RepoOwner,
+		RepoName:   bot.RepoName,
+		BotID:      bot.ID,
+		Steps:      steps,
+		InsertedAt: *bot.InsertedAt,
+	}
+
+	err = saveObject(g.sink, url+"/alllogs", PipelineBotsLogsListResponse{Items: []*contracts.BotLog{botLog}})
+	if err != nil {
+		return
+	}
+
+	return g.saveLogs(url, bot.BotStatus, botLog.ID, botLog, steps)
+}
+
+// saveLogs stores the log stream of the build, release or bot at url if it's still in progress, or its log by id otherwise
+func (g *Generator) saveLogs(url string, status contracts.Status, logID string, logObject interface{}, steps []*contracts.BuildLogStep) error {
+
+	if status == contracts.StatusPending || status == contracts.StatusRunning || status == contracts.StatusCanceling {
+		stream, err := generatedLogStream(steps)
+		if err != nil {
+			return err
+		}
+		return saveSSEBytes(g.sink, url+"/logs.stream", stream)
+	}
+
+	return saveObject(g.sink, fmt.Sprintf("%v/logsbyid/%v", url, logID), logObject)
+}
+
+// savePipelineSubResources stores the branches, bot names, warnings and stats of a pipeline
+func (g *Generator) savePipelineSubResources(url string, random *rand.Rand, pipeline *contracts.Pipeline, builds []*contracts.Build, releases []*contracts.Release, bots []*contracts.Bot) (err error) {
+
+	branchCounts := map[string]int{}
+	for _, b := range builds {
+		branchCounts[b.RepoBranch]++
+	}
+	botCounts := map[string]int{}
+	for _, b := range bots {
+		botCounts[b.Name]++
+	}
+	branches := PipelineBuildBranchesListResponse{Items: nameCounts(branchCounts)}
+	branches.Pagination = singlePage(len(branches.Items))
+	botNames := PipelineBotNamesListResponse{Items: nameCounts(botCounts)}
+	botNames.Pagination = singlePage(len(botNames.Items))
+
+	warnings := PipelineWarningsResponse{Warnings: []*contracts.Warning{}}
+	if random.Intn(3) == 0 {
+		warnings.Warnings = append(warnings.Warnings, &contracts.Warning{Status: "warning", Message: "This pipeline uses a builder image with the dev tag, pin it to a stable version instead."})
+	}
+
+	buildDurations := PipelineDurationsResponse{Durations: []*DurationMeasurement{}}
+	buildsCPU := PipelineMeasurementsResponse{Measurements: []*ResourceMeasurement{}}
+	buildsMemory := PipelineMeasurementsResponse{Measurements: []*ResourceMeasurement{}}
+	for _, b := range builds {
+		if b.BuildStatus == contracts.StatusRunning {
+			continue
+		}
+		cpu, memory := 0.2+random.Float64()*1.8, float64(128+random.Intn(1920))*1024*1024
+		buildDurations.Durations = append(buildDurations.Durations, &DurationMeasurement{InsertedAt: b.InsertedAt, Duration: b.Duration, PendingDuration: b.PendingDuration})
+		buildsCPU.Measurements = append(buildsCPU.Measurements, &ResourceMeasurement{InsertedAt: b.InsertedAt, MaxCPUUsage: &cpu})
+		buildsMemory.Measurements = append(buildsMemory.Measurements, &ResourceMeasurement{InsertedAt: b.InsertedAt, MaxMemoryUsage: &memory})
+	}
+
+	releaseDurations := PipelineDurationsResponse{Durations: []*DurationMeasurement{}}
+	releasesCPU := PipelineMeasurementsResponse{Measurements: []*ResourceMeasurement{}}
+	releasesMemory := PipelineMeasurementsResponse{Measurements: []*ResourceMeasurement{}}
+	for _, r := range releases {
+		cpu, memory := 0.1+random.Float64()*0.5, float64(64+random.Intn(192))*1024*1024
+		releaseDurations.Durations = append(releaseDurations.Durations, &DurationMeasurement{Name: r.Name, InsertedAt: *r.InsertedAt, Duration: *r.Duration, PendingDuration: r.PendingDuration})
+		releasesCPU.Measurements = append(releasesCPU.Measurements, &ResourceMeasurement{Name: r.Name, InsertedAt: *r.InsertedAt, MaxCPUUsage: &cpu})
+		releasesMemory.Measurements = append(releasesMemory.Measurements, &ResourceMeasurement{Name: r.Name, InsertedAt: *r.InsertedAt, MaxMemoryUsage: &memory})
+	}
+
+	for subPath, response := range map[string]interface{}{
+		"buildbranches":           branches,
+		"botnames":                botNames,
+		"warnings":                warnings,
+		"stats/buildsdurations":   buildDurations,
+		"stats/buildscpu":         buildsCPU,
+		"stats/buildsmemory":      buildsMemory,
+		"stats/releasesdurations": releaseDurations,
+		"stats/releasescpu":       releasesCPU,
+		"stats/releasesmemory":    releasesMemory,
+	} {
+		err = saveObject(g.sink, url+"/"+subPath, response)
+		if err != nil {
+			return
+		}
+	}
+
+	return nil
+}
+
+// generateGlobals stores the catalog and insights endpoints for the generated pipelines
+func (g *Generator) generateGlobals(ctx context.Context) (err error) {
+
+	span, _ := opentracing.StartSpanFromContext(ctx, "GenerateGlobals")
+	defer span.Finish()
+
+	scope := newPipelineScope(g.pipelines)
+
+	// catalog entities form an organization > team > service tree
+	entities := CatalogEntitiesListResponse{Items: []*contracts.CatalogEntity{}}
+	organizations := OrganizationsListResponse{Items: []*contracts.Organization{}}
+	teams := map[string]bool{}
+	for _, owner := range sortedKeys(scope.organizations) {
+		entities.Items = append(entities.Items, &contracts.CatalogEntity{Key: "organization", Value: owner})
+		organizations.Items = append(organizations.Items, &contracts.Organization{Name: owner})
+	}
+	for _, p := range g.pipelines {
+		team := labelValue(p.Labels, "team")
+		if !teams[p.RepoOwner+"/"+team] {
+			teams[p.RepoOwner+"/"+team] = true
+			entities.Items = append(entities.Items, &contracts.CatalogEntity{ParentKey: "organization", ParentValue: p.RepoOwner, Key: "team", Value: team})
+		}
+		entities.Items = append(entities.Items, &contracts.CatalogEntity{
+			ParentKey:      "team",
+			ParentValue:    team,
+			Key:            "service",
+			Value:          p.RepoName,
+			LinkedPipeline: p.GetFullRepoPath(),
+			Labels:         p.Labels,
+			Metadata:       map[string]interface{}{"language": labelValue(p.Labels, "language")},
+		})
+	}
+	entities.Pagination = singlePage(len(entities.Items))
+	organizations.Pagination = singlePage(len(organizations.Items))
+
+	labels := LabelsListResponse{Items: []*contracts.Label{}}
+	frequentLabels := FrequentLabelsListResponse{Items: []*LabelCount{}}
+	for l, count := range scope.labelCounts {
+		l := l
+		labels.Items = append(labels.Items, &l)
+		frequentLabels.Items = append(frequentLabels.Items, &LabelCount{Key: l.Key, Value: l.Value, PipelinesCount: count})
+	}
+	sort.Slice(labels.Items, func(i, j int) bool {
+		return labels.Items[i].Key+"="+labels.Items[i].Value < labels.Items[j].Key+"="+labels.Items[j].Value
+	})
+	sort.SliceStable(frequentLabels.Items, func(i, j int) bool {
+		if frequentLabels.Items[i].PipelinesCount != frequentLabels.Items[j].PipelinesCount {
+			return frequentLabels.Items[i].PipelinesCount > frequentLabels.Items[j].PipelinesCount
+		}
+		return frequentLabels.Items[i].Key+"="+frequentLabels.Items[i].Value < frequentLabels.Items[j].Key+"="+frequentLabels.Items[j].Value
+	})
+	labels.Pagination = singlePage(len(labels.Items))
+	frequentLabels.Pagination = singlePage(len(frequentLabels.Items))
+
+	releaseTargets := ReleaseTargetsListResponse{Items: []*ReleaseTargetCount{}}
+	for _, target := range generatedTargets {
+		if count := scope.releaseTargetCounts[target]; count > 0 {
+			releaseTargets.Items = append(releaseTargets.Items, &ReleaseTargetCount{Name: target, PipelinesCount: count})
+		}
+	}
+	releaseTargets.Pagination = singlePage(len(releaseTargets.Items))
+
+	mostBuilds := pipelineCounts(g.pipelines, func(p *contracts.Pipeline) int { return g.options.BuildsPerPipeline })
+	mostReleases := pipelineCounts(g.pipelines, func(p *contracts.Pipeline) int { return g.releaseCounts[p.GetFullRepoPath()] })
+
+	for path, list := range map[string]struct {
+		list  interface{}
+		items interface{}
+	}{
+		"/api/catalog/entities":   {entities, entities.Items},
+		"/api/labels":             {labels, labels.Items},
+		"/api/labels/frequent":    {frequentLabels, frequentLabels.Items},
+		"/api/stats/mostbuilds":   {mostBuilds, mostBuilds.Items},
+		"/api/stats/mostreleases": {mostReleases, mostReleases.Items},
+		"/api/releasetargets":     {releaseTargets, releaseTargets.Items},
+		"/api/organizations":      {organizations, organizations.Items},
+	} {
+		err = saveList(g.sink, path, list.list, list.items, g.options.PageSize)
+		if err != nil {
+			return
+		}
+	}
+
+	err = saveObject(g.sink, "/api/catalog/filters", []string{"organization", "team", "service"})
+	if err != nil {
+		return
+	}
+
+	log.Info().Msgf("Generated catalog and insights for %v pipelines", len(g.pipelines))
+
+	return nil
+}
+
+// newID returns the next generated id
+func (g *Generator) newID() string {
+	id := g.nextID
+	g.nextID++
+	return fmt.Sprint(id)
+}
+
+// generatedBuildStages returns the build stages of a pipeline written in language, some of them using a database service
+func generatedBuildStages(random *rand.Rand, language string) []logStage {
+
+	build := logStage{Name: "build", StepType: language + "-build"}
+	if random.Intn(3) == 0 {
+		build.Services = []string{"postgres"}
+	}
+
+	return []logStage{
+		{Name: "git-clone", StepType: "git-clone", AutoInjected: true},
+		build,
+		{Name: "bake", StepType: "docker-build"},
+		{Name: "push-to-docker-registry", StepType: "docker-push"},
+	}
+}
+
+// generatedReleaseStages are the stages of every release of a generated pipeline
+var generatedReleaseStages = []logStage{
+	{Name: "git-clone", StepType: "git-clone", AutoInjected: true},
+	{Name: "deploy", StepType: "helm-deploy"},
+}
+
+// generatedManifest returns the .estafette.yaml of a generated pipeline, with a release per target
+func generatedManifest(pipeline *contracts.Pipeline, stages []logStage, targets []string) string {
+
+	var manifest strings.Builder
+	fmt.Fprintf(&manifest, "builder:\n  track: stable\n\nlabels:\n")
+	for _, l := range pipeline.Labels {
+		fmt.Fprintf(&manifest, "  %v: %v\n", l.Key, l.Value)
+	}
+	fmt.Fprintf(&manifest, "\nstages:\n")
+	for _, stage := range stages {
+		if !stage.AutoInjected {
+			fmt.Fprintf(&manifest, "  %v:\n    image: %v\n", stage.Name, stepTemplates[stage.StepType].image)
+		}
+		for _, service := range stage.Services {
+			fmt.Fprintf(&manifest, "    services:\n    - name: %v\n      image: %v\n", service, stepTemplates[service].image)
+		}
+	}
+	fmt.Fprintf(&manifest, "\nreleases:\n")
+	for _, target := range targets {
+		fmt.Fprintf(&manifest, "  %v:\n    stages:\n", target)
+		for _, stage := range generatedReleaseStages {
+			if !stage.AutoInjected {
+				fmt.Fprintf(&manifest, "      %v:\n        image: %v\n", stage.Name, stepTemplates[stage.StepType].image)
+			}
+		}
+	}
+
+	return manifest.String()
+}
+
+// synthesizeBuildLog returns the log steps of build and sets its duration and update time to when they finished
+func synthesizeBuildLog(synthesizer *LogSynthesizer, pipeline *contracts.Pipeline, build *contracts.Build, stages []logStage) []*contracts.BuildLogStep {
+
+	variables := generatedLogVariables(pipeline, build.RepoBranch, build.RepoRevision, build.BuildVersion)
+	steps, duration := synthesizer.Steps(stages, build.BuildStatus, *build.StartedAt, variables)
+
+	build.UpdatedAt = build.StartedAt.Add(duration)
+	if build.BuildStatus != contracts.StatusRunning {
+		build.Duration = duration
+	}
+
+	return steps
+}
+
+// synthesizeReleaseLog returns the log steps of release and sets its duration and update time to when they finished
+func synthesizeReleaseLog(synthesizer *LogSynthesizer, pipeline *contracts.Pipeline, release *contracts.Release) []*contracts.BuildLogStep {
+
+	variables := generatedLogVariables(pipeline, pipeline.RepoBranch, "", release.ReleaseVersion)
+	variables["TARGET"] = release.Name
+	steps, duration := synthesizer.Steps(generatedReleaseStages, release.ReleaseStatus, *release.StartedAt, variables)
+
+	updatedAt := release.StartedAt.Add(duration)
+	release.UpdatedAt = &updatedAt
+	release.Duration = &duration
+
+	return steps
+}
+
+// synthesizeBotLog returns the log steps of bot and sets its duration and update time to when they finished
+func synthesizeBotLog(synthesizer *LogSynthesizer, pipeline *contracts.Pipeline, bot *contracts.Bot) []*contracts.BuildLogStep {
+
+	variables := generatedLogVariables(pipeline, pipeline.RepoBranch, "", "")
+	variables["BOT"] = bot.Name
+	steps, duration := synthesizer.Steps([]logStage{{Name: bot.Name, StepType: "bot"}}, bot.BotStatus, *bot.StartedAt, variables)
+
+	updatedAt := bot.StartedAt.Add(duration)
+	bot.UpdatedAt = &updatedAt
+	bot.Duration = &duration
+
+	return steps
+}
+
+// generatedLogVariables returns the variables available to the log lines of a run of pipeline
+func generatedLogVariables(pipeline *contracts.Pipeline, branch, revision, version string) map[string]string {
+	return map[string]string{
+		"REPO_SOURCE":          pipeline.RepoSource,
+		"REPO_OWNER":           pipeline.RepoOwner,
+		"REPO_NAME":            pipeline.RepoName,
+		"BRANCH":               branch,
+		"REVISION":             revision,
+		"BUILD_VERSION":        version,
+		"CONTAINER_REPOSITORY": pipeline.RepoOwner,
+		"NAMESPACE":            labelValue(pipeline.Labels, "team"),
+	}
+}
+
+// generatedLogEvent is the data of a log event in the log stream of a build, release or bot in progress
+type generatedLogEvent struct {
+	Step    string                 `json:"step"`
+	LogLine contracts.BuildLogLine `json:"logLine"`
+}
+
+// generatedLogStream returns the log lines of steps as server-sent events, as stored for an export
+func generatedLogStream(steps []*contracts.BuildLogStep) ([]byte, error) {
+
+	stream := []byte{}
+	for _, step := range steps {
+		for _, line := range step.LogLines {
+			data, err := json.Marshal(generatedLogEvent{Step: step.Step, LogLine: line})
+			if err != nil {
+				return nil, err
+			}
+			stream = append(stream, []byte("event:log\ndata:")...)
+			stream = append(stream, data...)
+			stream = append(stream, []byte("\n\n")...)
+		}
+	}
+
+	return stream, nil
+}
+
+func randomCommits(random *rand.Rand) []contracts.GitCommit {
+	commits := []contracts.GitCommit{}
+	for i := 0; i < 1+random.Intn(3); i++ {
+		commits = append(commits, contracts.GitCommit{
+			Message: fmt.Sprintf("%v %v", pick(random, generatedCommitVerbs), pick(random, generatedCommitThings)),
+			Author:  generatedAuthors[random.Intn(len(generatedAuthors))],
+		})
+	}
+	return commits
+}
+
+func randomRevision(random *rand.Rand) string {
+	return fmt.Sprintf("%016x%016x%08x", random.Uint64(), random.Uint64(), random.Uint32())
+}
+
+func pick(random *rand.Rand, values []string) string {
+	return values[random.Intn(len(values))]
+}
+
+// recentCommitters returns the distinct commit authors of builds, most recent first
+func recentCommitters(builds []*contracts.Build) []string {
+	committers := []string{}
+	seen := map[string]bool{}
+	for i := len(builds) - 1; i >= 0; i-- {
+		for _, c := range builds[i].Commits {
+			if !seen[c.Author.Email] {
+				seen[c.Author.Email] = true
+				committers = append(committers, c.Author.Email)
+			}
+		}
+	}
+	return committers
+}
+
+// mostRecentBuilds returns the limit most recent of builds, most recent first
+func mostRecentBuilds(builds []*contracts.Build, limit int) []*contracts.Build {
+	items := aggregateBuilds(builds).Items
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+// mostRecentReleases returns the limit most recent of releases, most recent first
+func mostRecentReleases(releases []*contracts.Release, limit int) []*contracts.Release {
+	items := aggregateReleases(releases).Items
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+func reverseBuilds(builds []*contracts.Build) []*contracts.Build {
+	reversed := make([]*contracts.Build, len(builds))
+	for i, b := range builds {
+		reversed[len(builds)-1-i] = b
+	}
+	return reversed
+}
+
+func reverseBots(bots []*contracts.Bot) []*contracts.Bot {
+	reversed := make([]*contracts.Bot, len(bots))
+	for i, b := range bots {
+		reversed[len(bots)-1-i] = b
+	}
+	return reversed
+}
+
+// nameCounts returns counts as list, highest count first
+func nameCounts(counts map[string]int) []*NameCount {
+	items := []*NameCount{}
+	for name, count := range counts {
+		items = append(items, &NameCount{Name: name, Count: count})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Name < items[j].Name
+	})
+	return items
+}
+
+// pipelineCounts returns the count of every pipeline as list, highest count first
+func pipelineCounts(pipelines []*contracts.Pipeline, count func(p *contracts.Pipeline) int) PipelineCountsListResponse {
+	counts := PipelineCountsListResponse{Items: []*PipelineCount{}}
+	for _, p := range pipelines {
+		counts.Items = append(counts.Items, &PipelineCount{RepoSource: p.RepoSource, RepoOwner: p.RepoOwner, RepoName: p.RepoName, NrRecords: count(p)})
+	}
+	sort.SliceStable(counts.Items, func(i, j int) bool { return counts.Items[i].NrRecords > counts.Items[j].NrRecords })
+	counts.Pagination = singlePage(len(counts.Items))
+	return counts
+}
+
+func labelValue(labels []contracts.Label, key string) string {
+	for _, l := range labels {
+		if l.Key == key {
+			return l.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+var testReferenceTime = time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func TestGeneratorRun(t *testing.T) {
+	t.Run("StoresDatasetInExportLayout", func(t *testing.T) {
+
+		sink := NewMemorySink()
+		generator := NewGenerator(sink, GeneratorOptions{Seed: 1, ReferenceTime: testReferenceTime, Pipelines: 3, BuildsPerPipeline: 4})
+
+		// act
+		err := generator.Run(context.Background())
+
+		assert.Nil(t, err)
+		files := sink.Files()
+		assert.Contains(t, files, "api/pipelines/index.json")
+		assert.Contains(t, files, "api/builds/index.json")
+		assert.Contains(t, files, "api/catalog/entities/index.json")
+		assert.Contains(t, files, "api/organizations/index.json")
+		assert.Equal(t, 3, len(generator.PipelinePaths()))
+		for _, path := range generator.PipelinePaths() {
+			url := "api/pipelines/" + path
+			assert.Contains(t, files, url+"/index.json")
+			assert.Contains(t, files, url+"/warnings/index.json")
+			assert.Contains(t, files, url+"/stats/releasesmemory/index.json")
+
+			var builds PipelineBuildsListResponse
+			err = json.Unmarshal(files[url+"/builds/index.json"], &builds)
+			assert.Nil(t, err)
+			assert.Equal(t, 4, len(builds.Items))
+			assert.Equal(t, "running", string(builds.Items[0].BuildStatus))
+			assert.Contains(t, files, url+"/builds/"+builds.Items[0].ID+"/logs.stream/index.json")
+			assert.Contains(t, files, url+"/builds/"+builds.Items[1].ID+"/alllogs/index.json")
+
+			var releases PipelineReleasesListResponse
+			err = json.Unmarshal(files[url+"/releases/index.json"], &releases)
+			assert.Nil(t, err)
+			for _, r := range releases.Items {
+				assert.Contains(t, files, url+"/releases/"+r.ID+"/index.json")
+			}
+		}
+		for path, content := range files {
+			if strings.HasSuffix(path, ".json") && !strings.Contains(path, "logs.stream") {
+				assert.True(t, json.Valid(content), path)
+			}
+		}
+	})
+
+	t.Run("GeneratesSameDatasetForSameSeed", func(t *testing.T) {
+
+		first, second, other := NewMemorySink(), NewMemorySink(), NewMemorySink()
+		options := GeneratorOptions{Seed: 7, ReferenceTime: testReferenceTime, Pipelines: 2}
+		err := NewGenerator(first, options).Run(context.Background())
+		assert.Nil(t, err)
+
+		// act
+		err = NewGenerator(second, options).Run(context.Background())
+
+		assert.Nil(t, err)
+		assert.Equal(t, first.Files(), second.Files())
+		options.Seed = 8
+		err = NewGenerator(other, options).Run(context.Background())
+		assert.Nil(t, err)
+		assert.NotEqual(t, first.Files(), other.Files())
+	})
+
+	t.Run("PassesVerificationOfExportDirectory", func(t *testing.T) {
+
+		directory, err := ioutil.TempDir("", "generate")
+		assert.Nil(t, err)
+		defer os.RemoveAll(directory)
+		generator := NewGenerator(NewDirectorySink(directory), GeneratorOptions{Seed: 1, ReferenceTime: testReferenceTime, Pipelines: 2})
+
+		// act
+		err = generator.Run(context.Background())
+
+		assert.Nil(t, err)
+		assert.Nil(t, verifyFixtureDirectory(directory))
+	})
+}
+
+func TestGeneratorOptionsWithDefaults(t *testing.T) {
+	t.Run("TakesSizesThatAreNotSetFromProfile", func(t *testing.T) {
+
+		options := GeneratorOptions{Profile: "deep", Pipelines: 2}
+
+		// act
+		options = options.withDefaults()
+
+		assert.Equal(t, 2, options.Pipelines)
+		assert.Equal(t, 10000, options.BuildsPerPipeline)
+		assert.Equal(t, 100, options.ReleasesPerTarget)
+		assert.Equal(t, defaultGenerateListLimit, options.ListLimit)
+	})
+
+	t.Run("FallsBackToDefaultProfileForUnknownProfile", func(t *testing.T) {
+
+		options := GeneratorOptions{Profile: "huge"}
+
+		// act
+		options = options.withDefaults()
+
+		assert.Equal(t, defaultGeneratorProfile, options.Profile)
+		assert.Equal(t, 10, options.Pipelines)
+		assert.Equal(t, 0, options.LogLinesPerStep)
+	})
+}
+
+func TestGeneratorRunWithLimits(t *testing.T) {
+	t.Run("KeepsMostRecentBuildsAndReleasesInListsAcrossPipelines", func(t *testing.T) {
+
+		sink := NewMemorySink()
+		generator := NewGenerator(sink, GeneratorOptions{Seed: 1, ReferenceTime: testReferenceTime, Pipelines: 5, BuildsPerPipeline: 6, ListLimit: 4})
+
+		// act
+		err := generator.Run(context.Background())
+
+		assert.Nil(t, err)
+		files := sink.Files()
+		var builds PipelineBuildsListResponse
+		err = json.Unmarshal(files["api/builds/index.json"], &builds)
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(builds.Items))
+		all := []*contracts.Build{}
+		for _, path := range generator.PipelinePaths() {
+			var pipelineBuilds PipelineBuildsListResponse
+			err = json.Unmarshal(files["api/pipelines/"+path+"/builds/index.json"], &pipelineBuilds)
+			assert.Nil(t, err)
+			assert.Equal(t, 6, len(pipelineBuilds.Items))
+			all = append(all, pipelineBuilds.Items...)
+		}
+		for i, b := range aggregateBuilds(all).Items[:4] {
+			assert.Equal(t, b.ID, builds.Items[i].ID)
+		}
+		var releases PipelineReleasesListResponse
+		err = json.Unmarshal(files["api/releases/index.json"], &releases)
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(releases.Items))
+	})
+
+	t.Run("PadsLogsToLogLinesPerStep", func(t *testing.T) {
+
+		sink := NewMemorySink()
+		generator := NewGenerator(sink, GeneratorOptions{Seed: 1, ReferenceTime: testReferenceTime, Pipelines: 1, BuildsPerPipeline: 2, LogLinesPerStep: 100})
+
+		// act
+		err := generator.Run(context.Background())
+
+		assert.Nil(t, err)
+		files := sink.Files()
+		var builds PipelineBuildsListResponse
+		err = json.Unmarshal(files["api/pipelines/"+generator.PipelinePaths()[0]+"/builds/index.json"], &builds)
+		assert.Nil(t, err)
+		var logs PipelineBuildsLogsListResponse
+		err = json.Unmarshal(files["api/pipelines/"+generator.PipelinePaths()[0]+"/builds/"+builds.Items[1].ID+"/alllogs/index.json"], &logs)
+		assert.Nil(t, err)
+		for _, step := range logs.Items[0].Steps {
+			if step.Status == contracts.LogStatusSucceeded {
+				assert.Equal(t, 100, len(step.LogLines), step.Step)
+			}
+		}
+	})
+}
+
+func TestGeneratedPipelinePaths(t *testing.T) {
+	t.Run("ReturnsUniquePaths", func(t *testing.T) {
+
+		// act
+		paths := generatedPipelinePaths(1, 500)
+
+		assert.Equal(t, 500, len(paths))
+		unique := map[string]bool{}
+		for _, p := range paths {
+			unique[p] = true
+		}
+		assert.Equal(t, 500, len(unique))
+	})
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rs/zerolog/log"
+)
+
+// globalPageSize is the page size used to fetch every item of the global lists before scoping them to the exported pipelines
+const globalPageSize = 100
+
+// extractGlobals stores the catalog and insights endpoints outside of /api/pipelines, limited to what relates to the exported pipelines
+func (e *Extractor) extractGlobals(ctx context.Context, token string, pipelines []*contracts.Pipeline) (err error) {
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ExtractGlobals")
+	defer span.Finish()
+
+	scope := newPipelineScope(pipelines)
+
+	// store catalog entities json
+	entities := CatalogEntitiesListResponse{Items: []*contracts.CatalogEntity{}}
+	err = fetchAllPages(func(pageNumber int) (contracts.Pagination, error) {
+		response, err := e.apiClient.GetCatalogEntities(ctx, token, pageNumber, globalPageSize, nil)
+		entities.Items = append(entities.Items, response.Items...)
+		return response.Pagination, err
+	})
+	if err != nil {
+		return
+	}
+	entities.Items = scope.catalogEntities(entities.Items)
+	for _, entity := range entities.Items {
+		e.obfuscator.CatalogEntity(entity)
+	}
+	entities.Pagination = singlePage(len(entities.Items))
+
+	err = e.saveList("/api/catalog/entities", entities, entities.Items)
+	if err != nil {
+		return
+	}
+
+	// store catalog filters json
+	filters, err := e.apiClient.GetCatalogFilters(ctx, token)
+	if err != nil {
+		return
+	}
+	err = e.saveObject("/api/catalog/filters", filters)
+	if err != nil {
+		return
+	}
+
+	// store labels json
+	labels := LabelsListResponse{Items: []*contracts.Label{}}
+	err = fetchAllPages(func(pageNumber int) (contracts.Pagination, error) {
+		response, err := e.apiClient.GetLabels(ctx, token, pageNumber, globalPageSize)
+		for _, l := range response.Items {
+			if l != nil && scope.labelCounts[*l] > 0 {
+				labels.Items = append(labels.Items, l)
+			}
+		}
+		return response.Pagination, err
+	})
+	if err != nil {
+		return
+	}
+	labels.Pagination = singlePage(len(labels.Items))
+
+	err = e.saveList("/api/labels", labels, labels.Items)
+	if err != nil {
+		return
+	}
+
+	// store frequent labels json, counting only the exported pipelines
+	frequentLabels := FrequentLabelsListResponse{Items: []*LabelCount{}}
+	err = fetchAllPages(func(pageNumber int) (contracts.Pagination, error) {
+		response, err := e.apiClient.GetFrequentLabels(ctx, token, pageNumber, globalPageSize)
+		for _, l := range response.Items {
+			if l == nil {
+				continue
+			}
+			if count := scope.labelCounts[contracts.Label{Key: l.Key, Value: l.Value}]; count > 0 {
+				l.PipelinesCount = count
+				frequentLabels.Items = append(frequentLabels.Items, l)
+			}
+		}
+		return response.Pagination, err
+	})
+	if err != nil {
+		return
+	}
+	frequentLabels.Pagination = singlePage(len(frequentLabels.Items))
+
+	err = e.saveList("/api/labels/frequent", frequentLabels, frequentLabels.Items)
+	if err != nil {
+		return
+	}
+
+	// store most builds and most releases json
+	for path, get := range map[string]func(pageNumber int) (PipelineCountsListResponse, error){
+		"/api/stats/mostbuilds": func(pageNumber int) (PipelineCountsListResponse, error) {
+			return e.apiClient.GetStatsMostBuilds(ctx, token, pageNumber, globalPageSize, nil)
+		},
+		"/api/stats/mostreleases": func(pageNumber int) (PipelineCountsListResponse, error) {
+			return e.apiClient.GetStatsMostReleases(ctx, token, pageNumber, globalPageSize, nil)
+		},
+	} {
+		counts := PipelineCountsListResponse{Items: []*PipelineCount{}}
+		err = fetchAllPages(func(pageNumber int) (contracts.Pagination, error) {
+			response, err := get(pageNumber)
+			for _, c := range response.Items {
+				if c != nil && scope.pipelines[fmt.Sprintf("%v/%v/%v", c.RepoSource, c.RepoOwner, c.RepoName)] {
+					counts.Items = append(counts.Items, c)
+				}
+			}
+			return response.Pagination, err
+		})
+		if err != nil {
+			return
+		}
+		counts.Pagination = singlePage(len(counts.Items))
+
+		err = e.saveList(path, counts, counts.Items)
+		if err != nil {
+			return
+		}
+	}
+
+	// store release targets json, counting only the exported pipelines
+	releaseTargets := ReleaseTargetsListResponse{Items: []*ReleaseTargetCount{}}
+	err = fetchAllPages(func(pageNumber int) (contracts.Pagination, error) {
+		response, err := e.apiClient.GetReleaseTargets(ctx, token, pageNumber, globalPageSize)
+		for _, rt := range response.Items {
+			if rt == nil {
+				continue
+			}
+			if count := scope.releaseTargetCounts[rt.Name]; count > 0 {
+				rt.PipelinesCount = count
+				releaseTargets.Items = append(releaseTargets.Items, rt)
+			}
+		}
+		return response.Pagination, err
+	})
+	if err != nil {
+		return
+	}
+	releaseTargets.Pagination = singlePage(len(releaseTargets.Items))
+
+	err = e.saveList("/api/releasetargets", releaseTargets, releaseTargets.Items)
+	if err != nil {
+		return
+	}
+
+	// store organizations json
+	organizations := OrganizationsListResponse{Items: []*contracts.Organization{}}
+	err = fetchAllPages(func(pageNumber int) (contracts.Pagination, error) {
+		response, err := e.apiClient.GetOrganizations(ctx, token, pageNumber, globalPageSize)
+		for _, o := range response.Items {
+			if o != nil && scope.organizations[o.Name] {
+				organizations.Items = append(organizations.Items, o)
+			}
+		}
+		return response.Pagination, err
+	})
+	if err != nil {
+		return
+	}
+	organizations.Pagination = singlePage(len(organizations.Items))
+
+	err = e.saveList("/api/organizations", organizations, organizations.Items)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msgf("Saved catalog and insights for %v pipelines", len(pipelines))
+
+	return nil
+}
+
+// fetchAllPages calls fetchPage for every page until the returned pagination indicates the last page has been fetched
+func fetchAllPages(fetchPage func(pageNumber int) (contracts.Pagination, error)) error {
+	for pageNumber := 1; ; pageNumber++ {
+		pagination, err := fetchPage(pageNumber)
+		if err != nil {
+			return err
+		}
+		if pageNumber >= pagination.TotalPages {
+			return nil
+		}
+	}
+}
+
+// singlePage returns the pagination for a list holding all of its totalItems in the first page
+func singlePage(totalItems int) contracts.Pagination {
+	return contracts.Pagination{
+		Page:       1,
+		Size:       globalPageSize,
+		TotalPages: 1,
+		TotalItems: totalItems,
+	}
+}
+
+// pipelineScope holds what the exported pipelines relate to, for limiting the global lists to them
+type pipelineScope struct {
+	pipelines           map[string]bool
+	labelCounts         map[contracts.Label]int
+	releaseTargetCounts map[string]int
+	organizations       map[string]bool
+}
+
+func newPipelineScope(pipelines []*contracts.Pipeline) *pipelineScope {
+
+	scope := &pipelineScope{
+		pipelines:           map[string]bool{},
+		labelCounts:         map[contracts.Label]int{},
+		releaseTargetCounts: map[string]int{},
+		organizations:       map[string]bool{},
+	}
+
+	for _, p := range pipelines {
+		scope.pipelines[p.GetFullRepoPath()] = true
+		for _, l := range p.Labels {
+			scope.labelCounts[l]++
+		}
+		for _, rt := range p.ReleaseTargets {
+			scope.releaseTargetCounts[rt.Name]++
+		}
+		for _, o := range p.Organizations {
+			if o != nil {
+				scope.organizations[o.Name] = true
+			}
+		}
+	}
+
+	return scope
+}
+
+// catalogEntities returns the entities linked to an exported pipeline together with all of their ancestors
+func (s *pipelineScope) catalogEntities(entities []*contracts.CatalogEntity) []*contracts.CatalogEntity {
+
+	type keyValue struct{ key, value string }
+
+	included := map[*contracts.CatalogEntity]bool{}
+	parents := map[keyValue]bool{}
+	for _, e := range entities {
+		if e != nil && e.LinkedPipeline != "" && s.pipelines[e.LinkedPipeline] {
+			included[e] = true
+			parents[keyValue{e.ParentKey, e.ParentValue}] = true
+		}
+	}
+
+	// include parents until no more ancestors are found
+	for added := true; added; {
+		added = false
+		for _, e := range entities {
+			if e != nil && !included[e] && parents[keyValue{e.Key, e.Value}] {
+				included[e] = true
+				parents[keyValue{e.ParentKey, e.ParentValue}] = true
+				added = true
+			}
+		}
+	}
+
+	scoped := []*contracts.CatalogEntity{}
+	for _, e := range entities {
+		if included[e] {
+			scoped = append(scoped, e)
+		}
+	}
+
+	return scoped
+}
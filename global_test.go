@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineScopeCatalogEntities(t *testing.T) {
+	t.Run("ReturnsLinkedEntitiesWithTheirAncestors", func(t *testing.T) {
+
+		scope := newPipelineScope([]*contracts.Pipeline{
+			{RepoSource: "github.com", RepoOwner: "estafette", RepoName: "estafette-ci-api"},
+		})
+		entities := []*contracts.CatalogEntity{
+			{Key: "organization", Value: "estafette"},
+			{ParentKey: "organization", ParentValue: "estafette", Key: "team", Value: "ci"},
+			{ParentKey: "organization", ParentValue: "estafette", Key: "team", Value: "other"},
+			{ParentKey: "team", ParentValue: "ci", Key: "service", Value: "api", LinkedPipeline: "github.com/estafette/estafette-ci-api"},
+			{ParentKey: "team", ParentValue: "other", Key: "service", Value: "secret", LinkedPipeline: "github.com/estafette/secret"},
+		}
+
+		// act
+		scoped := scope.catalogEntities(entities)
+
+		if assert.Equal(t, 3, len(scoped)) {
+			assert.Equal(t, "estafette", scoped[0].Value)
+			assert.Equal(t, "ci", scoped[1].Value)
+			assert.Equal(t, "api", scoped[2].Value)
+		}
+	})
+}
+
+func TestNewPipelineScope(t *testing.T) {
+	t.Run("CountsLabelsAndReleaseTargetsOfExportedPipelines", func(t *testing.T) {
+
+		// act
+		scope := newPipelineScope([]*contracts.Pipeline{
+			{Labels: []contracts.Label{{Key: "team", Value: "ci"}}, ReleaseTargets: []contracts.ReleaseTarget{{Name: "production"}}},
+			{Labels: []contracts.Label{{Key: "team", Value: "ci"}}, ReleaseTargets: []contracts.ReleaseTarget{{Name: "development"}, {Name: "production"}}},
+		})
+
+		assert.Equal(t, 2, scope.labelCounts[contracts.Label{Key: "team", Value: "ci"}])
+		assert.Equal(t, 2, scope.releaseTargetCounts["production"])
+		assert.Equal(t, 1, scope.releaseTargetCounts["development"])
+	})
+}
+
+func TestFetchAllPages(t *testing.T) {
+	t.Run("FetchesUntilTheLastPage", func(t *testing.T) {
+
+		fetched := []int{}
+
+		// act
+		err := fetchAllPages(func(pageNumber int) (contracts.Pagination, error) {
+			fetched = append(fetched, pageNumber)
+			return contracts.Pagination{Page: pageNumber, TotalPages: 3}, nil
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []int{1, 2, 3}, fetched)
+	})
+
+	t.Run("FetchesFirstPageOfEmptyList", func(t *testing.T) {
+
+		fetched := 0
+
+		// act
+		err := fetchAllPages(func(pageNumber int) (contracts.Pagination, error) {
+			fetched++
+			return contracts.Pagination{Page: pageNumber, TotalPages: 0}, nil
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, fetched)
+	})
+
+	t.Run("StopsAtError", func(t *testing.T) {
+
+		// act
+		err := fetchAllPages(func(pageNumber int) (contracts.Pagination, error) {
+			return contracts.Pagination{}, errors.New("unavailable")
+		})
+
+		assert.NotNil(t, err)
+	})
+}
@@ -1,6 +1,6 @@
 module github.com/estafette/estafette-ci-demo
 
-go 1.14
+go 1.16
 
 require (
 	github.com/alecthomas/kingpin v2.2.6+incompatible
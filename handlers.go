@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	endpointClassLists   = "lists"
+	endpointClassDetails = "details"
+	endpointClassLogs    = "logs"
+	endpointClassStreams = "streams"
+)
+
+var (
+	//go:embed handlers/GET.js.tmpl
+	handlerTemplate string
+
+	//go:embed handlers/GET-paged.js.tmpl
+	pagedHandlerTemplate string
+)
+
+// HandlerOptions configures the connect-api-mocker handler stored next to the responses of an endpoint class
+type HandlerOptions struct {
+	Delay       time.Duration
+	ContentType string
+	StatusCode  int
+}
+
+// DefaultHandlerOptions returns the handler options per endpoint class the demo uses unless configured otherwise
+func DefaultHandlerOptions() map[string]HandlerOptions {
+	return map[string]HandlerOptions{
+		endpointClassLists:   {Delay: 500 * time.Millisecond, ContentType: "application/json", StatusCode: 200},
+		endpointClassDetails: {Delay: 500 * time.Millisecond, ContentType: "application/json", StatusCode: 200},
+		endpointClassLogs:    {Delay: 500 * time.Millisecond, ContentType: "application/json", StatusCode: 200},
+		endpointClassStreams: {Delay: 5 * time.Second, ContentType: "text/event-stream", StatusCode: 200},
+	}
+}
+
+// parseHandlerOptions overrides the default handler options with delays, content types and status codes keyed by endpoint class
+func parseHandlerOptions(delays, contentTypes, statusCodes map[string]string) (options map[string]HandlerOptions, err error) {
+
+	options = DefaultHandlerOptions()
+
+	for class, value := range delays {
+		o, ok := options[class]
+		if !ok {
+			return nil, fmt.Errorf("Endpoint class %v is not supported, use one of lists, details, logs or streams", class)
+		}
+		o.Delay, err = time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+		options[class] = o
+	}
+
+	for class, value := range contentTypes {
+		o, ok := options[class]
+		if !ok {
+			return nil, fmt.Errorf("Endpoint class %v is not supported, use one of lists, details, logs or streams", class)
+		}
+		_, _, err = mime.ParseMediaType(value)
+		if err != nil {
+			return nil, fmt.Errorf("Content type %v for endpoint class %v is not a valid MIME type: %w", value, class, err)
+		}
+		o.ContentType = value
+		options[class] = o
+	}
+
+	for class, value := range statusCodes {
+		o, ok := options[class]
+		if !ok {
+			return nil, fmt.Errorf("Endpoint class %v is not supported, use one of lists, details, logs or streams", class)
+		}
+		o.StatusCode, err = strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+		options[class] = o
+	}
+
+	return options, nil
+}
+
+// endpointClass returns the class of the json endpoint at path, to pick the handler options for it
+func endpointClass(path string, query url.Values) string {
+
+	if isPageQuery(query) {
+		return endpointClassLists
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	last := segments[len(segments)-1]
+
+	switch {
+	case strings.Contains(path, "/logsbyid/") || last == "alllogs" || last == "logs":
+		return endpointClassLogs
+	case path == "/api/pipelines" || last == "builds" || last == "releases" || last == "bots":
+		return endpointClassLists
+	}
+
+	return endpointClassDetails
+}
+
+// mockerHandlers holds the rendered connect-api-mocker handlers per endpoint class
+type mockerHandlers struct {
+	handlers map[string][]byte
+	paged    []byte
+}
+
+func renderMockerHandlers(options map[string]HandlerOptions) (handlers *mockerHandlers, err error) {
+
+	handlers = &mockerHandlers{
+		handlers: map[string][]byte{},
+	}
+
+	for class, o := range options {
+		handlers.handlers[class], err = renderHandler(handlerTemplate, o)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	handlers.paged, err = renderHandler(pagedHandlerTemplate, options[endpointClassLists])
+	if err != nil {
+		return nil, err
+	}
+
+	return handlers, nil
+}
+
+func renderHandler(text string, options HandlerOptions) ([]byte, error) {
+
+	tmpl, err := template.New("handler").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHandlerOptions(t *testing.T) {
+	t.Run("OverridesDefaultsPerEndpointClass", func(t *testing.T) {
+
+		// act
+		options, err := parseHandlerOptions(map[string]string{"streams": "2s"}, map[string]string{"logs": "text/plain"}, map[string]string{"details": "404"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2*time.Second, options[endpointClassStreams].Delay)
+		assert.Equal(t, "text/plain", options[endpointClassLogs].ContentType)
+		assert.Equal(t, 404, options[endpointClassDetails].StatusCode)
+		assert.Equal(t, 500*time.Millisecond, options[endpointClassLists].Delay)
+	})
+
+	t.Run("ReturnsErrorForUnknownEndpointClass", func(t *testing.T) {
+
+		// act
+		_, err := parseHandlerOptions(map[string]string{"stats": "1s"}, nil, nil)
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsErrorForInvalidContentType", func(t *testing.T) {
+
+		// act
+		_, err := parseHandlerOptions(nil, map[string]string{"details": "text/plain'), require('child_process"}, nil)
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestEndpointClass(t *testing.T) {
+	t.Run("ReturnsClassForPath", func(t *testing.T) {
+
+		assert.Equal(t, endpointClassLists, endpointClass("/api/pipelines", nil))
+		assert.Equal(t, endpointClassLists, endpointClass("/api/pipelines/github.com/estafette/estafette-ci-api/builds", nil))
+		assert.Equal(t, endpointClassDetails, endpointClass("/api/pipelines/github.com/estafette/estafette-ci-api/builds/5", nil))
+		assert.Equal(t, endpointClassLogs, endpointClass("/api/pipelines/github.com/estafette/estafette-ci-api/builds/5/alllogs", nil))
+		assert.Equal(t, endpointClassLogs, endpointClass("/api/pipelines/github.com/estafette/estafette-ci-api/builds/5/logsbyid/7", nil))
+		assert.Equal(t, endpointClassLists, endpointClass("/api/pipelines/github.com/estafette/estafette-ci-api/buildbranches", pageQuery(1, 10)))
+	})
+}
+
+func TestRenderMockerHandlers(t *testing.T) {
+	t.Run("RendersOptionsIntoHandler", func(t *testing.T) {
+
+		options := DefaultHandlerOptions()
+
+		// act
+		handlers, err := renderMockerHandlers(options)
+
+		assert.Nil(t, err)
+		assert.Contains(t, string(handlers.handlers[endpointClassStreams]), "delay(5000), status(200), type('text/event-stream')")
+		assert.Contains(t, string(handlers.paged), "res.statusCode = 200")
+	})
+
+	t.Run("EscapesContentTypeInJavaScriptStrings", func(t *testing.T) {
+
+		options := DefaultHandlerOptions()
+		options[endpointClassLists] = HandlerOptions{ContentType: `application/json; profile="it's"`, StatusCode: 200}
+
+		// act
+		handlers, err := renderMockerHandlers(options)
+
+		assert.Nil(t, err)
+		assert.Contains(t, string(handlers.handlers[endpointClassLists]), `type('application/json; profile\u003D\"it\'s\"')`)
+		assert.Contains(t, string(handlers.paged), `res.setHeader('Content-Type', 'application/json; profile\u003D\"it\'s\"')`)
+	})
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	harModeNone     = "none"
+	harModePipeline = "pipeline"
+	harModeRun      = "run"
+)
+
+// see http://www.softwareishard.com/blog/har-12-spec/ for the HTTP Archive 1.2 format
+
+type harLog struct {
+	Log harLogContent `json:"log"`
+}
+
+type harLogContent struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harStrippedHeaders are left out of archived requests and responses; they hold credentials, or no longer match the obfuscated body
+var harStrippedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Content-Length"}
+
+// HarExchanges keeps the start time, method and headers of api requests with the status and headers of their responses by path, so
+// HTTP Archive entries hold the exchange as it happened
+type HarExchanges struct {
+	mutex     sync.Mutex
+	exchanges map[string]harExchange
+	remapper  *IDRemapper
+}
+
+type harExchange struct {
+	startedAt       time.Time
+	method          string
+	requestHeaders  http.Header
+	status          int
+	responseHeaders http.Header
+}
+
+// NewHarExchanges returns an empty HarExchanges
+func NewHarExchanges() *HarExchanges {
+	return &HarExchanges{
+		exchanges: map[string]harExchange{},
+	}
+}
+
+// RemapIDs keeps the exchanges by the path with the ids from remapper, as stored by a sink remapping ids; a nil remapper keeps them
+// by the path as requested
+func (e *HarExchanges) RemapIDs(remapper *IDRemapper) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.remapper = remapper
+}
+
+// Add keeps the exchange of response for path, started at startedAt; pages of a list share the exchange of the list, so the last one is kept
+func (e *HarExchanges) Add(path string, startedAt time.Time, response *http.Response) {
+
+	path = strings.SplitN(path, "?", 2)[0]
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.remapper != nil {
+		path = e.remapper.Path(path)
+	}
+
+	e.exchanges[path] = harExchange{
+		startedAt:       startedAt,
+		method:          response.Request.Method,
+		requestHeaders:  response.Request.Header.Clone(),
+		status:          response.StatusCode,
+		responseHeaders: response.Header.Clone(),
+	}
+}
+
+// Get returns the exchange for path, if there's any
+func (e *HarExchanges) Get(path string) (harExchange, bool) {
+
+	if e == nil {
+		return harExchange{}, false
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	exchange, ok := e.exchanges[path]
+	return exchange, ok
+}
+
+// harExchangeTransport adds the exchange of every request to the api to exchanges
+type harExchangeTransport struct {
+	http.RoundTripper
+	apiBaseURL string
+	exchanges  *HarExchanges
+}
+
+func (t *harExchangeTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+
+	startedAt := time.Now()
+	response, err := t.RoundTripper.RoundTrip(request)
+	if err == nil {
+		t.exchanges.Add(strings.TrimPrefix(request.URL.String(), t.apiBaseURL), startedAt, response)
+	}
+
+	return response, err
+}
+
+// harArchive collects the stored responses as HAR entries, grouped per pipeline or for the entire run
+type harArchive struct {
+	apiBaseURL string
+	mode       string
+	directory  string
+	exchanges  *HarExchanges
+	mutex      sync.Mutex
+	entries    map[string][]harEntry
+}
+
+// NewHarSink returns a sink storing responses as HTTP Archive files in directory, one per pipeline or one for the entire run depending on mode;
+// entries get the method, status and headers of the exchange kept in exchanges for their path
+func NewHarSink(apiBaseURL, mode, directory string, exchanges *HarExchanges) FixtureSink {
+	return newHarArchive(apiBaseURL, mode, directory, exchanges)
+}
+
+func newHarArchive(apiBaseURL, mode, directory string, exchanges *HarExchanges) *harArchive {
+	return &harArchive{
+		apiBaseURL: strings.TrimSuffix(apiBaseURL, "/"),
+		mode:       mode,
+		directory:  directory,
+		exchanges:  exchanges,
+		entries:    map[string][]harEntry{},
+	}
+}
+
+func (a *harArchive) WriteJSON(path string, query url.Values, bytes []byte) error {
+	a.addEntry(path, query, "application/json", bytes)
+	return nil
+}
+
+func (a *harArchive) WriteSSE(path string, bytes []byte) error {
+	a.addEntry(path, nil, "text/event-stream", bytes)
+	return nil
+}
+
+// WriteFile ignores plain files, an HTTP Archive only holds responses
+func (a *harArchive) WriteFile(name string, bytes []byte) error {
+	return nil
+}
+
+func (a *harArchive) Finalize() error {
+	return a.save()
+}
+
+// addEntry records the obfuscated response stored for path and query, with the exchange kept for path or, if there's none, with
+// the content type of the response only, started now
+func (a *harArchive) addEntry(path string, query url.Values, mimeType string, bytes []byte) {
+
+	exchange, ok := a.exchanges.Get(path)
+	if !ok {
+		exchange = harExchange{
+			startedAt:       time.Now(),
+			method:          http.MethodGet,
+			requestHeaders:  http.Header{},
+			status:          http.StatusOK,
+			responseHeaders: http.Header{"Content-Type": []string{mimeType}},
+		}
+	}
+	if contentType := exchange.responseHeaders.Get("Content-Type"); contentType != "" {
+		mimeType = contentType
+	}
+
+	requestURL := a.apiBaseURL + path
+	queryString := []harNameValue{}
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+		keys := make([]string, 0, len(query))
+		for k := range query {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, v := range query[k] {
+				queryString = append(queryString, harNameValue{Name: k, Value: v})
+			}
+		}
+	}
+
+	entry := harEntry{
+		StartedDateTime: exchange.startedAt.UTC(),
+		Request: harRequest{
+			Method:      exchange.method,
+			URL:         requestURL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(exchange.requestHeaders),
+			QueryString: queryString,
+			HeadersSize: -1,
+			BodySize:    0,
+		},
+		Response: harResponse{
+			Status:      exchange.status,
+			StatusText:  http.StatusText(exchange.status),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(exchange.responseHeaders),
+			Content: harContent{
+				Size:     len(bytes),
+				MimeType: mimeType,
+				Text:     string(bytes),
+			},
+			HeadersSize: -1,
+			BodySize:    len(bytes),
+		},
+	}
+
+	key := a.archiveName(path)
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.entries[key] = append(a.entries[key], entry)
+}
+
+// harHeaders returns headers sorted by name as HAR name-value pairs, without the stripped ones
+func harHeaders(headers http.Header) []harNameValue {
+
+	headers = headers.Clone()
+	for _, name := range harStrippedHeaders {
+		headers.Del(name)
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := []harNameValue{}
+	for _, name := range names {
+		for _, value := range headers[name] {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+
+	return pairs
+}
+
+// archiveName returns the file name of the archive the response for path belongs to
+func (a *harArchive) archiveName(path string) string {
+	if a.mode == harModePipeline {
+		segments := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api/pipelines"), "/"), "/")
+		if strings.HasPrefix(path, "/api/pipelines/") && len(segments) >= 3 {
+			return strings.Join(segments[0:3], "_") + ".har"
+		}
+		return "pipelines.har"
+	}
+
+	return "run.har"
+}
+
+// save writes one archive file per group into the directory
+func (a *harArchive) save() (err error) {
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	err = os.MkdirAll(a.directory, os.ModePerm)
+	if err != nil {
+		return
+	}
+
+	for name, entries := range a.entries {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Request.URL < entries[j].Request.URL
+		})
+
+		archive := harLog{
+			Log: harLogContent{
+				Version: "1.2",
+				Creator: harCreator{
+					Name:    app,
+					Version: version,
+				},
+				Entries: entries,
+			},
+		}
+
+		bytes, err := json.MarshalIndent(archive, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		err = ioutil.WriteFile(filepath.Join(a.directory, name), bytes, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
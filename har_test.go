@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHarArchive(t *testing.T) {
+	t.Run("SavesOneArchivePerPipeline", func(t *testing.T) {
+
+		directory, err := ioutil.TempDir("", "har")
+		assert.Nil(t, err)
+		defer os.RemoveAll(directory)
+
+		sink := NewHarSink("https://api.estafette.io/", harModePipeline, directory, nil)
+		err = sink.WriteJSON("/api/pipelines", nil, []byte(`{"items":[]}`))
+		assert.Nil(t, err)
+		err = sink.WriteJSON("/api/pipelines/github.com/estafette/estafette-ci-api/builds", pageQuery(2, 5), []byte(`{"items":[]}`))
+		assert.Nil(t, err)
+
+		// act
+		err = sink.Finalize()
+
+		assert.Nil(t, err)
+		bytes, err := ioutil.ReadFile(filepath.Join(directory, "github.com_estafette_estafette-ci-api.har"))
+		assert.Nil(t, err)
+		var archiveLog harLog
+		err = json.Unmarshal(bytes, &archiveLog)
+		assert.Nil(t, err)
+		assert.Equal(t, "1.2", archiveLog.Log.Version)
+		assert.Equal(t, 1, len(archiveLog.Log.Entries))
+		assert.Equal(t, "https://api.estafette.io/api/pipelines/github.com/estafette/estafette-ci-api/builds?page%5Bnumber%5D=2&page%5Bsize%5D=5", archiveLog.Log.Entries[0].Request.URL)
+		assert.Equal(t, 2, len(archiveLog.Log.Entries[0].Request.QueryString))
+		for _, h := range archiveLog.Log.Entries[0].Request.Headers {
+			assert.NotEqual(t, "Authorization", h.Name)
+		}
+		_, err = os.Stat(filepath.Join(directory, "pipelines.har"))
+		assert.Nil(t, err)
+	})
+
+	t.Run("SavesRecordedStatusAndHeadersWithoutAuthorization", func(t *testing.T) {
+
+		directory, err := ioutil.TempDir("", "har")
+		assert.Nil(t, err)
+		defer os.RemoveAll(directory)
+
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Set-Cookie", "session=abc")
+			w.Write([]byte(`{"id":"5"}`))
+		}))
+		defer api.Close()
+
+		exchanges := NewHarExchanges()
+		apiClient := NewApiClientWithOptions(api.URL, ApiClientOptions{HarExchanges: exchanges})
+		path := "/api/pipelines/github.com/estafette/estafette-ci-api/builds/5"
+		requestedAt := time.Now()
+		bytes, err := apiClient.GetBytesResponse(context.Background(), "abc", path)
+		assert.Nil(t, err)
+		respondedAt := time.Now()
+		sink := NewHarSink(api.URL, harModeRun, directory, exchanges)
+		err = sink.WriteJSON(path, nil, bytes)
+		assert.Nil(t, err)
+
+		// act
+		err = sink.Finalize()
+
+		assert.Nil(t, err)
+		bytes, err = ioutil.ReadFile(filepath.Join(directory, "run.har"))
+		assert.Nil(t, err)
+		var archiveLog harLog
+		err = json.Unmarshal(bytes, &archiveLog)
+		assert.Nil(t, err)
+		entry := archiveLog.Log.Entries[0]
+		assert.False(t, entry.StartedDateTime.Before(requestedAt))
+		assert.False(t, entry.StartedDateTime.After(respondedAt))
+		assert.Equal(t, http.MethodGet, entry.Request.Method)
+		assert.Contains(t, entry.Request.Headers, harNameValue{Name: "Content-Type", Value: "application/json"})
+		for _, h := range entry.Request.Headers {
+			assert.NotEqual(t, "Authorization", h.Name)
+		}
+		assert.Equal(t, http.StatusOK, entry.Response.Status)
+		assert.Contains(t, entry.Response.Headers, harNameValue{Name: "Cache-Control", Value: "no-cache"})
+		assert.Equal(t, "application/json; charset=utf-8", entry.Response.Content.MimeType)
+		for _, h := range entry.Response.Headers {
+			assert.NotEqual(t, "Set-Cookie", h.Name)
+		}
+	})
+}
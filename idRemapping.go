@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	idKindBuild   = "build"
+	idKindRelease = "release"
+	idKindBot     = "bot"
+	idKindLog     = "log"
+)
+
+// idKindsBySegment are the resources with remapped ids by the path segment listing them within a pipeline
+var idKindsBySegment = map[string]string{
+	"builds":   idKindBuild,
+	"releases": idKindRelease,
+	"bots":     idKindBot,
+}
+
+const (
+	// remapped ids have 19 digits like the ids of the api, while still fitting a signed 64-bit integer
+	minRemappedID   = 1000000000000000000
+	remappedIDRange = 8000000000000000000
+)
+
+// IDRemapper replaces the ids of builds, releases, bots and logs with ids derived from a secret key, so the same id is always replaced
+// by the same one, while the replacements don't reveal the original ids or how many of them exist; ids of pipelines, catalog entities,
+// organizations and other resources are left as they are
+type IDRemapper struct {
+	key []byte
+}
+
+// NewIDRemapper returns an IDRemapper deriving ids from key, or from a random key if it's empty
+func NewIDRemapper(key string) (*IDRemapper, error) {
+
+	if key != "" {
+		return &IDRemapper{key: []byte(key)}, nil
+	}
+
+	randomKey := make([]byte, 32)
+	_, err := rand.Read(randomKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IDRemapper{key: randomKey}, nil
+}
+
+// ID returns the id replacing id
+func (r *IDRemapper) ID(id string) string {
+
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(id))
+	sum := binary.BigEndian.Uint64(mac.Sum(nil))
+
+	return strconv.FormatUint(minRemappedID+sum%remappedIDRange, 10)
+}
+
+// Path replaces the ids in a fixture path, which are at fixed positions after /api/pipelines/{source}/{owner}/{repo},
+// like the build and log id in /api/pipelines/github.com/estafette/repo/builds/<id>/logsbyid/<id>
+func (r *IDRemapper) Path(path string) string {
+
+	segments, ok := pipelinePathSegments(path)
+	if !ok {
+		return path
+	}
+
+	if len(segments) > 4 && idKindsBySegment[segments[3]] != "" {
+		segments[4] = r.ID(segments[4])
+	}
+	if len(segments) > 6 && segments[5] == "logsbyid" {
+		segments[6] = r.ID(segments[6])
+	}
+
+	return "/api/pipelines/" + strings.Join(segments, "/")
+}
+
+// JSON replaces the ids in the json response stored for path, leaving every other byte as it is
+func (r *IDRemapper) JSON(path string, data []byte) ([]byte, error) {
+
+	raw, err := decodeRawJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	edits := map[string]string{}
+	r.collectIDEdits(raw, idKindForPath(path), "", edits)
+
+	return editJSONStrings(data, edits)
+}
+
+// SSE replaces the ids in the json data of the server-sent events stored for path, leaving lines without json as they are
+func (r *IDRemapper) SSE(path string, data []byte) ([]byte, error) {
+
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		value := bytes.TrimSpace(line[len("data:"):])
+		if len(value) == 0 || (value[0] != '{' && value[0] != '[') {
+			continue
+		}
+		remapped, err := r.JSON(path, value)
+		if err != nil {
+			return nil, fmt.Errorf("Remapping ids in event data failed: %w", err)
+		}
+		lines[i] = append([]byte("data: "), remapped...)
+	}
+
+	return bytes.Join(lines, []byte("\n")), nil
+}
+
+// collectIDEdits adds the replacement of the ids within raw to edits; the id field of raw itself is only replaced if kind is set,
+// as is the one of list items, while the id field of other nested objects like organizations is left as it is
+func (r *IDRemapper) collectIDEdits(raw interface{}, kind, path string, edits map[string]string) {
+
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			childPath := jsonChildPath(path, key)
+			switch strings.ToLower(key) {
+			case "id":
+				if kind != "" {
+					r.collectIDEdit(value, childPath, edits)
+				}
+			case "buildid", "releaseid", "botid":
+				r.collectIDEdit(value, childPath, edits)
+			case "items":
+				r.collectIDEdits(value, kind, childPath, edits)
+			case "activereleases":
+				r.collectIDEdits(value, idKindRelease, childPath, edits)
+			default:
+				r.collectIDEdits(value, "", childPath, edits)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			r.collectIDEdits(value, kind, jsonIndexPath(path, i), edits)
+		}
+	}
+}
+
+// collectIDEdit adds the replacement of the id at path to edits, if it's a non-empty string
+func (r *IDRemapper) collectIDEdit(value interface{}, path string, edits map[string]string) {
+	if id, ok := value.(string); ok && id != "" {
+		edits[path] = r.ID(id)
+	}
+}
+
+// idKindForPath returns the kind of resource with a remapped id the response for path holds, either directly or as list, if any
+func idKindForPath(path string) string {
+
+	switch path {
+	case "/api/builds":
+		return idKindBuild
+	case "/api/releases":
+		return idKindRelease
+	}
+
+	segments, ok := pipelinePathSegments(path)
+	if !ok || len(segments) < 4 {
+		return ""
+	}
+
+	kind := idKindsBySegment[segments[3]]
+	switch {
+	case kind == "" || len(segments) <= 5:
+		return kind
+	case segments[5] == "alllogs" || segments[5] == "logsbyid":
+		return idKindLog
+	}
+
+	return ""
+}
+
+// pipelinePathSegments returns the segments of path after /api/pipelines/, starting with the source, owner and name of the pipeline
+func pipelinePathSegments(path string) ([]string, bool) {
+	if !strings.HasPrefix(path, "/api/pipelines/") {
+		return nil, false
+	}
+	return strings.Split(strings.TrimPrefix(path, "/api/pipelines/"), "/"), true
+}
+
+// idRemappingSink replaces the ids in the paths and responses of fixtures before passing them on to another sink
+type idRemappingSink struct {
+	sink     FixtureSink
+	remapper *IDRemapper
+}
+
+// NewIDRemappingSink returns a sink replacing the ids in all fixtures with the ids from remapper before writing them to sink
+func NewIDRemappingSink(sink FixtureSink, remapper *IDRemapper) FixtureSink {
+	return &idRemappingSink{
+		sink:     sink,
+		remapper: remapper,
+	}
+}
+
+func (s *idRemappingSink) WriteJSON(path string, query url.Values, bytes []byte) error {
+
+	remapped, err := s.remapper.JSON(path, bytes)
+	if err != nil {
+		return fmt.Errorf("Remapping ids in response for %v failed: %w", path, err)
+	}
+
+	return s.sink.WriteJSON(s.remapper.Path(path), query, remapped)
+}
+
+func (s *idRemappingSink) WriteSSE(path string, bytes []byte) error {
+
+	remapped, err := s.remapper.SSE(path, bytes)
+	if err != nil {
+		return fmt.Errorf("Remapping ids in response for %v failed: %w", path, err)
+	}
+
+	return s.sink.WriteSSE(s.remapper.Path(path), remapped)
+}
+
+func (s *idRemappingSink) WriteFile(name string, bytes []byte) error {
+	return s.sink.WriteFile(name, bytes)
+}
+
+func (s *idRemappingSink) Finalize() error {
+	return s.sink.Finalize()
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDRemapperID(t *testing.T) {
+	t.Run("ReturnsSameIDForSameKey", func(t *testing.T) {
+
+		first, err := NewIDRemapper("key")
+		assert.Nil(t, err)
+		second, err := NewIDRemapper("key")
+		assert.Nil(t, err)
+
+		// act
+		id := first.ID("1001")
+
+		assert.Equal(t, second.ID("1001"), id)
+		assert.NotEqual(t, first.ID("1002"), id)
+		assert.Equal(t, 19, len(id))
+	})
+
+	t.Run("ReturnsOtherIDForOtherKey", func(t *testing.T) {
+
+		first, err := NewIDRemapper("key")
+		assert.Nil(t, err)
+		second, err := NewIDRemapper("other-key")
+		assert.Nil(t, err)
+
+		// act
+		id := first.ID("1001")
+
+		assert.NotEqual(t, second.ID("1001"), id)
+	})
+
+	t.Run("ReturnsOtherIDForRandomKey", func(t *testing.T) {
+
+		first, err := NewIDRemapper("")
+		assert.Nil(t, err)
+		second, err := NewIDRemapper("")
+		assert.Nil(t, err)
+
+		// act
+		id := first.ID("1001")
+
+		assert.NotEqual(t, second.ID("1001"), id)
+	})
+}
+
+func TestIDRemapperPath(t *testing.T) {
+	t.Run("ReplacesIDsAfterBuildsReleasesBotsAndLogsByID", func(t *testing.T) {
+
+		remapper, err := NewIDRemapper("key")
+		assert.Nil(t, err)
+
+		// act
+		path := remapper.Path("/api/pipelines/github.com/estafette/repo/releases/1001/logsbyid/1002")
+
+		assert.Equal(t, "/api/pipelines/github.com/estafette/repo/releases/"+remapper.ID("1001")+"/logsbyid/"+remapper.ID("1002"), path)
+	})
+
+	t.Run("ReplacesOnlyIDsAtFixedPositions", func(t *testing.T) {
+
+		remapper, err := NewIDRemapper("key")
+		assert.Nil(t, err)
+
+		// act
+		path := remapper.Path("/api/pipelines/github.com/builds/bots/builds/1")
+
+		assert.Equal(t, "/api/pipelines/github.com/builds/bots/builds/"+remapper.ID("1"), path)
+	})
+
+	t.Run("KeepsPathsWithoutIDs", func(t *testing.T) {
+
+		remapper, err := NewIDRemapper("key")
+		assert.Nil(t, err)
+
+		// act
+		path := remapper.Path("/api/pipelines/github.com/estafette/repo/builds")
+
+		assert.Equal(t, "/api/pipelines/github.com/estafette/repo/builds", path)
+	})
+}
+
+func TestIDRemapperJSON(t *testing.T) {
+	t.Run("ReplacesIDFieldsKeepingOtherBytes", func(t *testing.T) {
+
+		remapper, err := NewIDRemapper("key")
+		assert.Nil(t, err)
+		data := []byte(`{"items": [{"id":"1001", "buildID": "1001","name":"1001", "count": 3}],
+  "pagination": {"totalItems": 1}}`)
+
+		// act
+		remapped, err := remapper.JSON("/api/pipelines/github.com/estafette/repo/builds/1001/alllogs", data)
+
+		assert.Nil(t, err)
+		assert.Equal(t, `{"items": [{"id":"`+remapper.ID("1001")+`", "buildID": "`+remapper.ID("1001")+`","name":"1001", "count": 3}],
+  "pagination": {"totalItems": 1}}`, string(remapped))
+	})
+
+	t.Run("ReplacesOnlyIDsOfBuildsReleasesBotsAndLogs", func(t *testing.T) {
+
+		remapper, err := NewIDRemapper("key")
+		assert.Nil(t, err)
+		data := []byte(`{"id":"1","organizations":[{"id":"2"}],"releaseTargets":[{"activeReleases":[{"id":"3","groups":[{"id":"4"}]}]}]}`)
+
+		// act
+		pipeline, err := remapper.JSON("/api/pipelines/github.com/estafette/repo", data)
+		assert.Nil(t, err)
+		build, err := remapper.JSON("/api/pipelines/github.com/estafette/repo/builds/1", data)
+		assert.Nil(t, err)
+
+		assert.Equal(t, `{"id":"1","organizations":[{"id":"2"}],"releaseTargets":[{"activeReleases":[{"id":"`+remapper.ID("3")+`","groups":[{"id":"4"}]}]}]}`, string(pipeline))
+		assert.Equal(t, `{"id":"`+remapper.ID("1")+`","organizations":[{"id":"2"}],"releaseTargets":[{"activeReleases":[{"id":"`+remapper.ID("3")+`","groups":[{"id":"4"}]}]}]}`, string(build))
+	})
+
+	t.Run("ReturnsErrorForInvalidJSON", func(t *testing.T) {
+
+		remapper, err := NewIDRemapper("key")
+		assert.Nil(t, err)
+
+		// act
+		_, err = remapper.JSON("/api/builds", []byte(`{"id":`))
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestIDRemapperSSE(t *testing.T) {
+	t.Run("ReplacesIDsInEventData", func(t *testing.T) {
+
+		remapper, err := NewIDRemapper("key")
+		assert.Nil(t, err)
+
+		// act
+		remapped, err := remapper.SSE("/api/pipelines/github.com/estafette/repo/builds/1001/logs.stream", []byte("event: log\ndata: {\"buildID\":\"1001\"}\n\nevent: close\ndata: eof\n\n"))
+
+		assert.Nil(t, err)
+		assert.Equal(t, "event: log\ndata: {\"buildID\":\""+remapper.ID("1001")+"\"}\n\nevent: close\ndata: eof\n\n", string(remapped))
+	})
+}
+
+func TestIDRemappingSink(t *testing.T) {
+	t.Run("PassesRemappedPathAndResponseToSink", func(t *testing.T) {
+
+		remapper, err := NewIDRemapper("key")
+		assert.Nil(t, err)
+		memory := NewMemorySink()
+		sink := NewIDRemappingSink(memory, remapper)
+
+		// act
+		err = sink.WriteJSON("/api/pipelines/github.com/estafette/repo/builds/1001", url.Values{}, []byte(`{"id":"1001"}`))
+
+		assert.Nil(t, err)
+		assert.Equal(t, `{"id":"`+remapper.ID("1001")+`"}`, string(memory.Files()["api/pipelines/github.com/estafette/repo/builds/"+remapper.ID("1001")+"/index.json"]))
+	})
+
+	t.Run("HidesAllBuildReleaseBotAndLogIDsOfExtractedPipelines", func(t *testing.T) {
+
+		dataset := newFakeDataset(2, 3)
+		remapper, err := NewIDRemapper("key")
+		assert.Nil(t, err)
+		memory := NewMemorySink()
+		extractor := NewExtractor(NewFakeApiClient(dataset), NewIDRemappingSink(memory, remapper), newTestObfuscator(t), ExtractorOptions{Pipelines: dataset.pipelinePaths()})
+
+		// act
+		err = extractor.Run(context.Background())
+
+		assert.Nil(t, err)
+		files := memory.Files()
+		url := "api/pipelines/github.com/estafette/fake-pipeline-2/bots/" + remapper.ID("2003")
+		var logs PipelineBotsLogsListResponse
+		err = json.Unmarshal(files[url+"/alllogs/index.json"], &logs)
+		assert.Nil(t, err)
+		assert.Equal(t, remapper.ID("2003"), logs.Items[0].BotID)
+		assert.Contains(t, files, url+"/logsbyid/"+logs.Items[0].ID+"/index.json")
+		for _, pipeline := range []string{"1", "2"} {
+			for item := 1; item <= 3; item++ {
+				id := fmt.Sprintf("%v%03d", pipeline, item)
+				for path, content := range files {
+					assert.NotContains(t, "/"+path, "/"+id+"/", path)
+					assert.NotContains(t, string(content), `"`+id+`"`, path)
+				}
+			}
+		}
+		assert.Contains(t, string(files["api/pipelines/github.com/estafette/fake-pipeline-2/index.json"]), `"id": "2"`)
+	})
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+)
+
+// logStage is a stage of a manifest as the log synthesizer runs it
+type logStage struct {
+	Name         string
+	StepType     string
+	AutoInjected bool
+
+	// Services holds the step types of services running alongside the stage
+	Services []string
+
+	// NestedStages run in parallel as part of the stage
+	NestedStages []logStage
+}
+
+// logLineTemplate is a line printed by a step type, with ${VARIABLE} references expanded from the variables of a run
+type logLineTemplate struct {
+	stream   string
+	text     string
+	maxDelay time.Duration
+}
+
+// stepFailure is a way a step type can fail, printing its lines after the first afterLine lines of regular output
+type stepFailure struct {
+	afterLine int
+	exitCode  int64
+	lines     []logLineTemplate
+}
+
+// stepTemplate holds the image and regular output of a step type and the ways it can fail
+type stepTemplate struct {
+	image    string
+	lines    []logLineTemplate
+	failures []stepFailure
+}
+
+func stdout(text string, maxDelay time.Duration) logLineTemplate {
+	return logLineTemplate{stream: "stdout", text: text, maxDelay: maxDelay}
+}
+
+func stderr(text string, maxDelay time.Duration) logLineTemplate {
+	return logLineTemplate{stream: "stderr", text: text, maxDelay: maxDelay}
+}
+
+var stepTemplates = map[string]stepTemplate{
+	"git-clone": {
+		image: "extensions/git-clone:stable",
+		lines: []logLineTemplate{
+			stdout("Cloning git repository ${REPO_SOURCE}/${REPO_OWNER}/${REPO_NAME} to branch ${BRANCH} and revision ${REVISION} with shallow clone depth 50...", time.Second),
+			stderr("Cloning into '/estafette-work'...", 2*time.Second),
+			stdout("Finished cloning git repository ${REPO_SOURCE}/${REPO_OWNER}/${REPO_NAME} to branch ${BRANCH} and revision ${REVISION}", 3*time.Second),
+		},
+		failures: []stepFailure{
+			{afterLine: 1, exitCode: 128, lines: []logLineTemplate{
+				stderr("fatal: could not read Username for 'https://${REPO_SOURCE}': terminal prompts disabled", 2*time.Second),
+			}},
+			{afterLine: 2, exitCode: 1, lines: []logLineTemplate{
+				stderr("fatal: reference is not a tree: ${REVISION}", time.Second),
+			}},
+		},
+	},
+	"golang-build": {
+		image: "golang:1.16-alpine",
+		lines: []logLineTemplate{
+			stdout("go: downloading github.com/rs/zerolog v1.20.0", 3*time.Second),
+			stdout("go: downloading github.com/stretchr/testify v1.7.0", time.Second),
+			stdout("=== RUN   TestHandler", 20*time.Second),
+			stdout("--- PASS: TestHandler (0.02s)", time.Second),
+			stdout("PASS", time.Second),
+			stdout("ok  \t${REPO_SOURCE}/${REPO_OWNER}/${REPO_NAME}\t0.412s", time.Second),
+			stdout("go build -a -installsuffix cgo -ldflags \"-X main.version=${BUILD_VERSION} -X main.revision=${REVISION}\" -o ./publish/${REPO_NAME} .", 40*time.Second),
+		},
+		failures: []stepFailure{
+			{afterLine: 2, exitCode: 2, lines: []logLineTemplate{
+				stderr("# ${REPO_SOURCE}/${REPO_OWNER}/${REPO_NAME}", 15*time.Second),
+				stderr("./handler.go:42:9: undefined: config", time.Second),
+				stderr("FAIL\t${REPO_SOURCE}/${REPO_OWNER}/${REPO_NAME} [build failed]", time.Second),
+			}},
+			{afterLine: 3, exitCode: 1, lines: []logLineTemplate{
+				stdout("    handler_test.go:27: ", time.Second),
+				stdout("        \tError:      \tNot equal: ", time.Second),
+				stdout("        \t            \texpected: 200", time.Second),
+				stdout("        \t            \tactual  : 500", time.Second),
+				stdout("--- FAIL: TestHandler (0.03s)", time.Second),
+				stdout("FAIL", time.Second),
+				stdout("FAIL\t${REPO_SOURCE}/${REPO_OWNER}/${REPO_NAME}\t0.451s", time.Second),
+			}},
+		},
+	},
+	"nodejs-build": {
+		image: "node:14-alpine",
+		lines: []logLineTemplate{
+			stdout("npm WARN deprecated request@2.88.2: request has been deprecated", 10*time.Second),
+			stdout("added 1024 packages from 712 contributors and audited 1030 packages in 31.4s", 30*time.Second),
+			stdout("> ${REPO_NAME}@${BUILD_VERSION} test /estafette-work", time.Second),
+			stdout("Test Suites: 12 passed, 12 total", 25*time.Second),
+			stdout("> ${REPO_NAME}@${BUILD_VERSION} build /estafette-work", time.Second),
+			stdout("Compiled successfully.", 45*time.Second),
+		},
+		failures: []stepFailure{
+			{afterLine: 3, exitCode: 1, lines: []logLineTemplate{
+				stderr("FAIL src/handler.test.js", 20*time.Second),
+				stdout("Test Suites: 1 failed, 11 passed, 12 total", time.Second),
+				stderr("npm ERR! Test failed.  See above for more details.", time.Second),
+			}},
+			{afterLine: 0, exitCode: 1, lines: []logLineTemplate{
+				stderr("npm ERR! code ETIMEDOUT", 60*time.Second),
+				stderr("npm ERR! network request to https://registry.npmjs.org/react failed, reason: connect ETIMEDOUT", time.Second),
+			}},
+		},
+	},
+	"docker-build": {
+		image: "extensions/docker:stable",
+		lines: []logLineTemplate{
+			stdout("Building docker image ${CONTAINER_REPOSITORY}/${REPO_NAME}:${BUILD_VERSION}...", time.Second),
+			stdout("Step 1/4 : FROM scratch", time.Second),
+			stdout("Step 2/4 : COPY ca-certificates.crt /etc/ssl/certs/", time.Second),
+			stdout("Step 3/4 : COPY ./publish/${REPO_NAME} /", time.Second),
+			stdout("Step 4/4 : ENTRYPOINT [\"/${REPO_NAME}\"]", time.Second),
+			stdout("Successfully built 3f2a9c1d7e4b", 2*time.Second),
+			stdout("Successfully tagged ${CONTAINER_REPOSITORY}/${REPO_NAME}:${BUILD_VERSION}", time.Second),
+		},
+		failures: []stepFailure{
+			{afterLine: 3, exitCode: 1, lines: []logLineTemplate{
+				stderr("COPY failed: stat /var/lib/docker/tmp/docker-builder/publish/${REPO_NAME}: no such file or directory", time.Second),
+			}},
+			{afterLine: 1, exitCode: 1, lines: []logLineTemplate{
+				stderr("toomanyrequests: You have reached your pull rate limit.", 5*time.Second),
+			}},
+		},
+	},
+	"docker-push": {
+		image: "extensions/docker:stable",
+		lines: []logLineTemplate{
+			stdout("Pushing docker image ${CONTAINER_REPOSITORY}/${REPO_NAME}:${BUILD_VERSION}...", time.Second),
+			stdout("The push refers to repository [docker.io/${CONTAINER_REPOSITORY}/${REPO_NAME}]", time.Second),
+			stdout("${BUILD_VERSION}: digest: sha256:9b2d54f1c3a7e8f0 size: 528", 10*time.Second),
+		},
+		failures: []stepFailure{
+			{afterLine: 2, exitCode: 1, lines: []logLineTemplate{
+				stderr("denied: requested access to the resource is denied", 2*time.Second),
+			}},
+		},
+	},
+	"helm-deploy": {
+		image: "extensions/helm:stable",
+		lines: []logLineTemplate{
+			stdout("Deploying ${REPO_NAME} version ${BUILD_VERSION} to ${TARGET}...", time.Second),
+			stdout("helm upgrade --install ${REPO_NAME} ./helm/${REPO_NAME} --namespace ${NAMESPACE} --set image.tag=${BUILD_VERSION} --wait --timeout 300s", 2*time.Second),
+			stdout("Release \"${REPO_NAME}\" has been upgraded. Happy Helming!", 60*time.Second),
+			stdout("NAME: ${REPO_NAME}", time.Second),
+			stdout("NAMESPACE: ${NAMESPACE}", time.Second),
+			stdout("STATUS: deployed", time.Second),
+		},
+		failures: []stepFailure{
+			{afterLine: 2, exitCode: 1, lines: []logLineTemplate{
+				stderr("Error: UPGRADE FAILED: timed out waiting for the condition", 300*time.Second),
+			}},
+			{afterLine: 1, exitCode: 1, lines: []logLineTemplate{
+				stderr("Error: UPGRADE FAILED: template: ${REPO_NAME}/templates/deployment.yaml:24:20: executing \"${REPO_NAME}/templates/deployment.yaml\" at <.Values.resources.limits>: nil pointer evaluating interface {}.limits", 2*time.Second),
+			}},
+		},
+	},
+	"postgres": {
+		image: "postgres:13-alpine",
+		lines: []logLineTemplate{
+			stdout("The files belonging to this database system will be owned by user \"postgres\".", time.Second),
+			stdout("PostgreSQL init process complete; ready for start up.", 3*time.Second),
+			stdout("LOG:  database system is ready to accept connections", time.Second),
+		},
+	},
+	"bot": {
+		image: "extensions/${BOT}:stable",
+		lines: []logLineTemplate{
+			stdout("Running ${BOT} for ${REPO_SOURCE}/${REPO_OWNER}/${REPO_NAME}...", time.Second),
+			stdout("Found 3 items to process", 5*time.Second),
+			stdout("Finished ${BOT}", 10*time.Second),
+		},
+		failures: []stepFailure{
+			{afterLine: 1, exitCode: 1, lines: []logLineTemplate{
+				stderr("Error: api rate limit exceeded", 2*time.Second),
+			}},
+		},
+	},
+}
+
+// LogSynthesizer fabricates the logs of builds, releases and bots from the templates of their step types
+type LogSynthesizer struct {
+	random       *rand.Rand
+	linesPerStep int
+}
+
+// NewLogSynthesizer returns a LogSynthesizer taking its randomness from random, padding the log of every step that runs to
+// linesPerStep lines by repeating the regular output of its step type; 0 keeps the lines of the step type
+func NewLogSynthesizer(random *rand.Rand, linesPerStep int) *LogSynthesizer {
+	return &LogSynthesizer{
+		random:       random,
+		linesPerStep: linesPerStep,
+	}
+}
+
+// stepMode is how far the synthesizer runs a step
+type stepMode int
+
+const (
+	stepSucceeds stepMode = iota
+	stepFails
+	stepRuns
+	stepCanceled
+	stepPending
+	stepSkipped
+)
+
+// Steps returns the log steps of running stages from startedAt until they reach status, expanding variables in the log lines;
+// a failed or canceled run stops at a random stage and a run in progress is still running one, it returns the duration of the run
+func (s *LogSynthesizer) Steps(stages []logStage, status contracts.Status, startedAt time.Time, variables map[string]string) ([]*contracts.BuildLogStep, time.Duration) {
+
+	// the stage where the run stops
+	stop := len(stages)
+	stopMode := stepSucceeds
+	switch status {
+	case contracts.StatusFailed:
+		stop, stopMode = s.stopStage(stages, stepFails), stepFails
+	case contracts.StatusRunning, contracts.StatusCanceling:
+		stop, stopMode = s.stopStage(stages, stepRuns), stepRuns
+	case contracts.StatusCanceled:
+		stop, stopMode = s.stopStage(stages, stepCanceled), stepCanceled
+	case contracts.StatusPending:
+		stop, stopMode = 0, stepPending
+	}
+
+	steps := []*contracts.BuildLogStep{}
+	timestamp := startedAt
+	for i, stage := range stages {
+		mode := stepSucceeds
+		switch {
+		case i == stop:
+			mode = stopMode
+		case i > stop && (stopMode == stepRuns || stopMode == stepPending):
+			mode = stepPending
+		case i > stop:
+			mode = stepSkipped
+		}
+
+		var step *contracts.BuildLogStep
+		step, timestamp = s.step(stage, mode, 0, timestamp, variables)
+		steps = append(steps, step)
+	}
+
+	return steps, timestamp.Sub(startedAt)
+}
+
+// stopStage picks the stage a run stops at in mode, preferring stages that aren't injected automatically unless they have ways
+// to fail for a failing run, like git-clone
+func (s *LogSynthesizer) stopStage(stages []logStage, mode stepMode) int {
+	candidates := []int{}
+	for i, stage := range stages {
+		if !stage.AutoInjected || mode == stepFails && len(stepTemplates[stage.StepType].failures) > 0 {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return s.random.Intn(len(stages))
+	}
+	return candidates[s.random.Intn(len(candidates))]
+}
+
+// step returns the log step of stage run in mode from startedAt, and the time it finished at
+func (s *LogSynthesizer) step(stage logStage, mode stepMode, depth int, startedAt time.Time, variables map[string]string) (*contracts.BuildLogStep, time.Time) {
+
+	template, ok := stepTemplates[stage.StepType]
+	if !ok {
+		template = stepTemplate{image: "alpine:3.13", lines: []logLineTemplate{stdout("Running "+stage.Name+"...", time.Second)}}
+	}
+
+	step := &contracts.BuildLogStep{
+		Step:         stage.Name,
+		Depth:        depth,
+		AutoInjected: stage.AutoInjected,
+		LogLines:     []contracts.BuildLogLine{},
+		Status:       contracts.LogStatusSucceeded,
+	}
+
+	switch mode {
+	case stepSkipped:
+		step.Status = contracts.LogStatusSkipped
+		return step, startedAt
+	case stepPending:
+		step.Status = contracts.LogStatusPending
+		return step, startedAt
+	}
+
+	// only steps that run get their image pulled
+	step.Image = s.image(expandLogVariables(template.image, variables))
+	timestamp := startedAt.Add(step.Image.PullDuration)
+	runStartedAt := timestamp
+
+	// services start along with the stage and keep running until it's done
+	for _, service := range stage.Services {
+		serviceStep, _ := s.step(logStage{Name: service, StepType: service}, stepSucceeds, depth, runStartedAt, variables)
+		step.Services = append(step.Services, serviceStep)
+	}
+
+	lines := paddedLogLines(template.lines, s.linesPerStep)
+	switch mode {
+	case stepFails:
+		if len(stage.NestedStages) == 0 && len(template.failures) > 0 {
+			failure := template.failures[s.random.Intn(len(template.failures))]
+			// the failure cuts the padded output at the same share of it as afterLine does for the regular output
+			cut := failure.afterLine * len(lines) / len(template.lines)
+			lines = append(append([]logLineTemplate{}, lines[:cut]...), failure.lines...)
+			step.ExitCode = failure.exitCode
+		} else if len(stage.NestedStages) == 0 {
+			lines = append(append([]logLineTemplate{}, lines...), stderr("exit status 1", time.Second))
+			step.ExitCode = 1
+		}
+		step.Status = contracts.LogStatusFailed
+	case stepRuns:
+		lines = lines[:s.random.Intn(len(lines)+1)]
+		step.Status = contracts.LogStatusRunning
+	case stepCanceled:
+		lines = lines[:s.random.Intn(len(lines)+1)]
+		step.Status = contracts.LogStatusCanceled
+	}
+
+	for _, line := range lines {
+		timestamp = timestamp.Add(time.Duration(1 + s.random.Int63n(int64(line.maxDelay))))
+		step.LogLines = append(step.LogLines, contracts.BuildLogLine{
+			LineNumber: len(step.LogLines) + 1,
+			Timestamp:  timestamp,
+			StreamType: line.stream,
+			Text:       expandLogVariables(line.text, variables),
+		})
+	}
+
+	// nested stages run in parallel, the stage takes as long as the slowest of them
+	if len(stage.NestedStages) > 0 {
+		failing := -1
+		if mode == stepFails {
+			failing = s.random.Intn(len(stage.NestedStages))
+		}
+		finishedAt := timestamp
+		for i, nested := range stage.NestedStages {
+			nestedMode := stepSucceeds
+			switch {
+			case i == failing:
+				nestedMode = stepFails
+			case mode == stepRuns || mode == stepCanceled:
+				nestedMode = mode
+			}
+			nestedStep, nestedFinishedAt := s.step(nested, nestedMode, depth+1, timestamp, variables)
+			step.NestedSteps = append(step.NestedSteps, nestedStep)
+			if nestedFinishedAt.After(finishedAt) {
+				finishedAt = nestedFinishedAt
+			}
+			if nestedStep.ExitCode != 0 {
+				step.ExitCode = nestedStep.ExitCode
+			}
+		}
+		timestamp = finishedAt
+	}
+
+	for _, service := range step.Services {
+		service.Duration = timestamp.Sub(runStartedAt)
+		service.Status = step.Status
+		if step.Status == contracts.LogStatusFailed {
+			service.Status = contracts.LogStatusSucceeded
+		}
+	}
+
+	if mode != stepRuns {
+		step.Duration = timestamp.Sub(runStartedAt)
+	}
+
+	return step, timestamp
+}
+
+// image returns the docker image of a step, pulled before it runs
+func (s *LogSynthesizer) image(image string) *contracts.BuildLogStepDockerImage {
+
+	name, tag := image, "latest"
+	if i := strings.LastIndex(image, ":"); i >= 0 {
+		name, tag = image[:i], image[i+1:]
+	}
+
+	return &contracts.BuildLogStepDockerImage{
+		Name:         name,
+		Tag:          tag,
+		IsPulled:     true,
+		ImageSize:    (20 + s.random.Int63n(780)) * 1024 * 1024,
+		PullDuration: time.Duration(500+s.random.Intn(15000)) * time.Millisecond,
+		IsTrusted:    strings.HasPrefix(name, "extensions/"),
+	}
+}
+
+// paddedLogLines returns lines with the ones between the first and the last repeated until there are count of them
+func paddedLogLines(lines []logLineTemplate, count int) []logLineTemplate {
+
+	if len(lines) == 0 || len(lines) >= count {
+		return lines
+	}
+
+	filler := lines
+	if len(lines) > 2 {
+		filler = lines[1 : len(lines)-1]
+	}
+
+	padded := make([]logLineTemplate, 0, count)
+	padded = append(padded, lines[:len(lines)-1]...)
+	for i := 0; len(padded) < count-1; i++ {
+		padded = append(padded, filler[i%len(filler)])
+	}
+
+	return append(padded, lines[len(lines)-1])
+}
+
+// expandLogVariables replaces ${VARIABLE} references in text with their value, leaving unknown references as they are
+func expandLogVariables(text string, variables map[string]string) string {
+	return os.Expand(text, func(key string) string {
+		if value, ok := variables[key]; ok {
+			return value
+		}
+		return "${" + key + "}"
+	})
+}
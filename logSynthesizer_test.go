@@ -0,0 +1,185 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+var testLogStages = []logStage{
+	{Name: "git-clone", StepType: "git-clone", AutoInjected: true},
+	{Name: "build", StepType: "golang-build", Services: []string{"postgres"}},
+	{Name: "bake", StepType: "docker-build"},
+	{Name: "push-to-docker-registry", StepType: "docker-push"},
+}
+
+var testLogVariables = map[string]string{
+	"REPO_SOURCE":          "github.com",
+	"REPO_OWNER":           "estafette",
+	"REPO_NAME":            "fake-pipeline-1",
+	"BRANCH":               "main",
+	"REVISION":             "0123456789abcdef0123456789abcdef01234567",
+	"BUILD_VERSION":        "1.0.3",
+	"CONTAINER_REPOSITORY": "estafette",
+}
+
+func TestLogSynthesizerSteps(t *testing.T) {
+	t.Run("ReturnsSucceededStepsWithExpandedVariables", func(t *testing.T) {
+
+		synthesizer := NewLogSynthesizer(rand.New(rand.NewSource(1)), 0)
+
+		// act
+		steps, duration := synthesizer.Steps(testLogStages, contracts.StatusSucceeded, testReferenceTime, testLogVariables)
+
+		assert.Equal(t, 4, len(steps))
+		assert.True(t, duration > 0)
+		for _, step := range steps {
+			assert.Equal(t, contracts.LogStatusSucceeded, step.Status)
+			assert.NotNil(t, step.Image)
+			assert.True(t, len(step.LogLines) > 0)
+			for _, line := range step.LogLines {
+				assert.NotContains(t, line.Text, "${")
+			}
+		}
+		assert.True(t, steps[0].AutoInjected)
+		assert.Equal(t, 1, len(steps[1].Services))
+		assert.Equal(t, "postgres", steps[1].Services[0].Step)
+		assert.Contains(t, steps[2].LogLines[0].Text, "estafette/fake-pipeline-1:1.0.3")
+	})
+
+	t.Run("ReturnsFailedStepFollowedBySkippedSteps", func(t *testing.T) {
+
+		synthesizer := NewLogSynthesizer(rand.New(rand.NewSource(2)), 0)
+
+		// act
+		steps, _ := synthesizer.Steps(testLogStages, contracts.StatusFailed, testReferenceTime, testLogVariables)
+
+		failed := -1
+		for i, step := range steps {
+			if step.Status == contracts.LogStatusFailed {
+				failed = i
+				assert.NotEqual(t, 0, step.ExitCode)
+			}
+		}
+		assert.True(t, failed >= 0)
+		for _, step := range steps[failed+1:] {
+			assert.Equal(t, contracts.LogStatusSkipped, step.Status)
+			assert.Nil(t, step.Image)
+			assert.Equal(t, 0, len(step.LogLines))
+		}
+	})
+
+	t.Run("PadsOutputOfFailedStepBeforeFailure", func(t *testing.T) {
+
+		// every git-clone failure starts after a third or two thirds of its regular output
+		stages := []logStage{{Name: "git-clone", StepType: "git-clone"}}
+
+		// act
+		steps, _ := NewLogSynthesizer(rand.New(rand.NewSource(2)), 30).Steps(stages, contracts.StatusFailed, testReferenceTime, testLogVariables)
+
+		assert.Equal(t, contracts.LogStatusFailed, steps[0].Status)
+		assert.Contains(t, []int{11, 21}, len(steps[0].LogLines))
+	})
+
+	t.Run("FailsAtGitCloneForSomeRuns", func(t *testing.T) {
+
+		failedAtGitClone := false
+		for seed := int64(0); seed < 50 && !failedAtGitClone; seed++ {
+
+			// act
+			steps, _ := NewLogSynthesizer(rand.New(rand.NewSource(seed)), 0).Steps(testLogStages, contracts.StatusFailed, testReferenceTime, testLogVariables)
+
+			failedAtGitClone = steps[0].Status == contracts.LogStatusFailed
+		}
+
+		assert.True(t, failedAtGitClone)
+	})
+
+	t.Run("ReturnsRunningStepFollowedByPendingSteps", func(t *testing.T) {
+
+		synthesizer := NewLogSynthesizer(rand.New(rand.NewSource(3)), 0)
+
+		// act
+		steps, _ := synthesizer.Steps(testLogStages, contracts.StatusRunning, testReferenceTime, testLogVariables)
+
+		running := -1
+		for i, step := range steps {
+			if step.Status == contracts.LogStatusRunning {
+				running = i
+				assert.Equal(t, 0, int(step.Duration))
+			}
+		}
+		assert.True(t, running > 0)
+		for _, step := range steps[running+1:] {
+			assert.Equal(t, contracts.LogStatusPending, step.Status)
+		}
+	})
+
+	t.Run("RunsNestedStagesInParallel", func(t *testing.T) {
+
+		synthesizer := NewLogSynthesizer(rand.New(rand.NewSource(4)), 0)
+		stages := []logStage{{Name: "tests", NestedStages: []logStage{
+			{Name: "unit", StepType: "golang-build"},
+			{Name: "lint", StepType: "nodejs-build"},
+		}}}
+
+		// act
+		steps, duration := synthesizer.Steps(stages, contracts.StatusSucceeded, testReferenceTime, testLogVariables)
+
+		assert.Equal(t, 1, len(steps))
+		assert.Equal(t, 2, len(steps[0].NestedSteps))
+		for _, nested := range steps[0].NestedSteps {
+			assert.Equal(t, 1, nested.Depth)
+			assert.True(t, nested.Duration < duration)
+		}
+	})
+
+	t.Run("ReturnsSameStepsForSameRandomSource", func(t *testing.T) {
+
+		first, _ := NewLogSynthesizer(rand.New(rand.NewSource(5)), 0).Steps(testLogStages, contracts.StatusFailed, testReferenceTime, testLogVariables)
+
+		// act
+		second, _ := NewLogSynthesizer(rand.New(rand.NewSource(5)), 0).Steps(testLogStages, contracts.StatusFailed, testReferenceTime, testLogVariables)
+
+		assert.Equal(t, first, second)
+	})
+}
+
+func TestPaddedLogLines(t *testing.T) {
+	t.Run("RepeatsLinesBetweenFirstAndLast", func(t *testing.T) {
+
+		lines := []logLineTemplate{stdout("first", time.Second), stdout("a", time.Second), stdout("b", time.Second), stdout("last", time.Second)}
+
+		// act
+		padded := paddedLogLines(lines, 7)
+
+		texts := []string{}
+		for _, l := range padded {
+			texts = append(texts, l.text)
+		}
+		assert.Equal(t, []string{"first", "a", "b", "a", "b", "a", "last"}, texts)
+	})
+
+	t.Run("KeepsLinesIfThereAreEnough", func(t *testing.T) {
+
+		lines := []logLineTemplate{stdout("first", time.Second), stdout("last", time.Second)}
+
+		// act
+		padded := paddedLogLines(lines, 1)
+
+		assert.Equal(t, lines, padded)
+	})
+}
+
+func TestExpandLogVariables(t *testing.T) {
+	t.Run("KeepsUnknownReferences", func(t *testing.T) {
+
+		// act
+		text := expandLogVariables("${REPO_NAME} ${UNKNOWN}", testLogVariables)
+
+		assert.Equal(t, "fake-pipeline-1 ${UNKNOWN}", text)
+	})
+}
@@ -5,17 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
-	"path/filepath"
-	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/kingpin"
-	contracts "github.com/estafette/estafette-ci-contracts"
 	foundation "github.com/estafette/estafette-foundation"
-	"github.com/opentracing/opentracing-go"
 	"github.com/rs/zerolog/log"
 	"github.com/uber/jaeger-client-go"
 	jaegercfg "github.com/uber/jaeger-client-go/config"
@@ -30,21 +26,85 @@ var (
 	buildDate string
 	goVersion = runtime.Version()
 
-	// params for apiClient
-	apiBaseURL   = kingpin.Flag("api-base-url", "The base url of the estafette-ci-api to communicate with").Envar("API_BASE_URL").Required().String()
-	clientID     = kingpin.Flag("client-id", "The id of the client as configured in Estafette, to securely communicate with the api.").Envar("CLIENT_ID").Required().String()
-	clientSecret = kingpin.Flag("client-secret", "The secret of the client as configured in Estafette, to securely communicate with the api.").Envar("CLIENT_SECRET").Required().String()
-
-	// other params for gsuiteClient
-	pipelinesToExtract = kingpin.Flag("pipelines-to-extract", "A comma separated list of pipelines to extract.").Envar("PIPELINES_TO_EXTRACT").Required().String()
-	saveToDirectory    = kingpin.Flag("save-to-directory", "Directory to store responses.").Default("./mocks").OverrideDefaultFromEnvar("SAVE_TO_DIRECTORY").String()
-	logObfuscateRegex  = kingpin.Flag("log-obfuscate-regex", "Regular expression to obfuscate parts of the logs").Envar("LOG_OBFUSCATE_REGEX").String()
+	saveToDirectory     = kingpin.Flag("save-to-directory", "Directory to store responses.").Default("./mocks").OverrideDefaultFromEnvar("SAVE_TO_DIRECTORY").String()
+	sinkType            = kingpin.Flag("sink", "How to store responses, as connect-api-mocker directory, plain directory, tar.gz or zip archive.").Default(sinkTypeMocker).OverrideDefaultFromEnvar("SINK").Enum(sinkTypeMocker, sinkTypeDirectory, sinkTypeTarGz, sinkTypeZip)
+	handlerDelays       = kingpin.Flag("handler-delay", "Delay of the connect-api-mocker handlers per endpoint class (lists, details, logs, streams), like streams=5s.").Envar("HANDLER_DELAY").StringMap()
+	handlerContentTypes = kingpin.Flag("handler-content-type", "Content type returned by the connect-api-mocker handlers per endpoint class, like logs=application/json.").Envar("HANDLER_CONTENT_TYPE").StringMap()
+	handlerStatusCodes  = kingpin.Flag("handler-status-code", "Status code returned by the connect-api-mocker handlers per endpoint class, like details=200.").Envar("HANDLER_STATUS_CODE").StringMap()
+	logObfuscateRegex   = kingpin.Flag("log-obfuscate-regex", "Regular expression to obfuscate parts of the logs").Envar("LOG_OBFUSCATE_REGEX").String()
+	remapIDs            = kingpin.Flag("remap-ids", "Replace the build, release, bot and log ids in extracted or recorded paths and responses, to hide the internal ids of the api.").Envar("REMAP_IDS").Bool()
+	idRemappingKey      = kingpin.Flag("id-remapping-key", "Secret key to derive the replacing ids from; keep it the same for stable ids between runs, a random key is used if empty.").Envar("ID_REMAPPING_KEY").String()
+
+	// params for apiClient, required by the commands talking to the api
+	apiBaseURL   = kingpin.Flag("api-base-url", "The base url of the estafette-ci-api to communicate with").Envar("API_BASE_URL").String()
+	clientID     = kingpin.Flag("client-id", "The id of the client as configured in Estafette, to securely communicate with the api.").Envar("CLIENT_ID").String()
+	clientSecret = kingpin.Flag("client-secret", "The secret of the client as configured in Estafette, to securely communicate with the api.").Envar("CLIENT_SECRET").String()
+
+	// extract command, the default when no command is given
+	extractCommand        = kingpin.Command("extract", "Extracts and obfuscates data from the api and stores it as mock responses.").Default().Validate(validateAPIFlags)
+	pipelinesToExtract    = extractCommand.Flag("pipelines-to-extract", "A comma separated list of pipelines to extract.").Envar("PIPELINES_TO_EXTRACT").Required().String()
+	pageSize              = extractCommand.Flag("page-size", "Split exported lists into pages of this size next to the full list, 0 disables paging.").Default("0").OverrideDefaultFromEnvar("PAGE_SIZE").Int()
+	harMode               = extractCommand.Flag("har-mode", "Store the responses as HTTP Archive as well, either one per pipeline or one for the entire run.").Default(harModeNone).OverrideDefaultFromEnvar("HAR_MODE").Enum(harModeNone, harModePipeline, harModeRun)
+	harDirectory          = extractCommand.Flag("har-directory", "Directory to store HTTP Archive files.").Default("./har").OverrideDefaultFromEnvar("HAR_DIRECTORY").String()
+	wireMockDirectory     = extractCommand.Flag("wiremock-directory", "Directory to store WireMock mappings and files in as well, disabled if empty.").Envar("WIREMOCK_DIRECTORY").String()
+	strictDecode          = extractCommand.Flag("strict-decode", "Report the fields in api responses unknown to the contracts version, per endpoint.").Envar("STRICT_DECODE").Bool()
+	preserveUnknownFields = extractCommand.Flag("preserve-unknown-fields", "Store the fields in api responses unknown to the contracts version instead of dropping them, implies strict-decode.").Envar("PRESERVE_UNKNOWN_FIELDS").Bool()
+	preserveRawJSON       = extractCommand.Flag("preserve-raw-json", "Store api responses with their original field order, nulls and number formats by obfuscating the raw json, where the response is stored as fetched.").Envar("PRESERVE_RAW_JSON").Bool()
+
+	// generate command
+	generateCommand           = kingpin.Command("generate", "Generates a synthetic dataset without any api access and stores it as mock responses.").Validate(validateGenerateFlags)
+	generateProfile           = generateCommand.Flag("profile", "Volume profile the sizes that aren't set are taken from.").Default(defaultGeneratorProfile).OverrideDefaultFromEnvar("GENERATE_PROFILE").Enum(generatorProfileNames()...)
+	generateSeed              = generateCommand.Flag("seed", "Seed for the generated dataset, the same seed and reference time generate the same dataset.").Default("1").OverrideDefaultFromEnvar("GENERATE_SEED").Int64()
+	generateReferenceTime     = generateCommand.Flag("reference-time", "Time the most recent builds happen at in RFC3339 format, defaults to the start of the current day.").Envar("GENERATE_REFERENCE_TIME").String()
+	generatePipelines         = generateCommand.Flag("pipelines", "Number of pipelines to generate, defaults to the one of the profile.").Default("0").OverrideDefaultFromEnvar("GENERATE_PIPELINES").Int()
+	generateBuildsPerPipeline = generateCommand.Flag("builds-per-pipeline", "Number of builds to generate per pipeline, defaults to the one of the profile.").Default("0").OverrideDefaultFromEnvar("GENERATE_BUILDS_PER_PIPELINE").Int()
+	generateReleasesPerTarget = generateCommand.Flag("releases-per-target", "Number of releases to generate per release target of a pipeline, defaults to the one of the profile.").Default("0").OverrideDefaultFromEnvar("GENERATE_RELEASES_PER_TARGET").Int()
+	generateBotsPerPipeline   = generateCommand.Flag("bots-per-pipeline", "Number of bot runs to generate per pipeline, defaults to the one of the profile.").Default("0").OverrideDefaultFromEnvar("GENERATE_BOTS_PER_PIPELINE").Int()
+	generateLogLinesPerStep   = generateCommand.Flag("log-lines-per-step", "Pad the log of every step to this number of lines, defaults to the one of the profile.").Default("0").OverrideDefaultFromEnvar("GENERATE_LOG_LINES_PER_STEP").Int()
+	generateListLimit         = generateCommand.Flag("list-limit", "Maximum number of most recent builds and releases in the lists across pipelines.").Default("1000").OverrideDefaultFromEnvar("GENERATE_LIST_LIMIT").Int()
+	generatePageSize          = generateCommand.Flag("page-size", "Split generated lists into pages of this size next to the full list, 0 disables paging.").Default("0").OverrideDefaultFromEnvar("PAGE_SIZE").Int()
+	generateChecksums         = generateCommand.Flag("checksums", "Record the checksum of every generated file in the manifest, which keeps an entry per file in memory.").Envar("GENERATE_CHECKSUMS").Bool()
+
+	// augment command
+	augmentCommand           = kingpin.Command("augment", "Adds builds, releases and bots cloned from the exported ones to every pipeline of an export and stores the result as mock responses.")
+	augmentDirectory         = augmentCommand.Arg("export", "Directory of the export to augment, which can be the directory to store responses.").Required().String()
+	augmentSeed              = augmentCommand.Flag("seed", "Seed for the added runs, the same seed and export give the same augmented export.").Default("1").OverrideDefaultFromEnvar("AUGMENT_SEED").Int64()
+	augmentBuildsPerPipeline = augmentCommand.Flag("builds-per-pipeline", "Number of builds to add to every pipeline.").Default("20").OverrideDefaultFromEnvar("AUGMENT_BUILDS_PER_PIPELINE").Int()
+	augmentReleasesPerTarget = augmentCommand.Flag("releases-per-target", "Number of releases to add for every release target of a pipeline.").Default("5").OverrideDefaultFromEnvar("AUGMENT_RELEASES_PER_TARGET").Int()
+	augmentBotsPerPipeline   = augmentCommand.Flag("bots-per-pipeline", "Number of bot runs to add to every pipeline.").Default("5").OverrideDefaultFromEnvar("AUGMENT_BOTS_PER_PIPELINE").Int()
+	augmentPageSize          = augmentCommand.Flag("page-size", "Split augmented lists into pages of this size next to the full list, 0 disables paging.").Default("0").OverrideDefaultFromEnvar("PAGE_SIZE").Int()
+
+	// serve command
+	serveCommand  = kingpin.Command("serve", "Serves the stored responses as a mock api.")
+	listenAddress = serveCommand.Flag("listen-address", "The address to serve the mock api on.").Default(":5000").OverrideDefaultFromEnvar("LISTEN_ADDRESS").String()
+
+	// record command
+	recordCommand       = kingpin.Command("record", "Proxies requests to the api and stores each obfuscated response as mock response.").Validate(validateAPIFlags)
+	recordListenAddress = recordCommand.Flag("listen-address", "The address to serve the recording proxy on.").Default("127.0.0.1:5000").OverrideDefaultFromEnvar("LISTEN_ADDRESS").String()
+
+	// diff command
+	diffCommand      = kingpin.Command("diff", "Compares two exports semantically and reports the changes.")
+	diffOldDirectory = diffCommand.Arg("old", "Directory of the previous export.").Required().String()
+	diffNewDirectory = diffCommand.Arg("new", "Directory of the new export.").Required().String()
+	diffFormat       = diffCommand.Flag("format", "Format of the report.").Default("text").Enum("text", "json")
+
+	// publish command
+	publishCommand           = kingpin.Command("publish", "Replaces the export in a target git repository and commits it if it changed.")
+	publishRepository        = publishCommand.Flag("repository", "The git repository to clone if the checkout directory doesn't hold a clone yet.").Envar("PUBLISH_REPOSITORY").String()
+	publishBranch            = publishCommand.Flag("branch", "The branch to commit to.").Default("main").OverrideDefaultFromEnvar("PUBLISH_BRANCH").String()
+	publishCheckoutDirectory = publishCommand.Flag("checkout-directory", "The directory holding the clone of the target repository.").Default("./estafette-ci-web").OverrideDefaultFromEnvar("PUBLISH_CHECKOUT_DIRECTORY").String()
+	publishTargetDirectory   = publishCommand.Flag("target-directory", "The directory in the target repository holding the export.").Default("mocks").OverrideDefaultFromEnvar("PUBLISH_TARGET_DIRECTORY").String()
+	publishSubdirectory      = publishCommand.Flag("subdirectory", "The part of the export to replace, next to the manifest and the endpoints it lists.").Default("api/pipelines").OverrideDefaultFromEnvar("PUBLISH_SUBDIRECTORY").String()
+	publishMessage           = publishCommand.Flag("message", "Template for the commit message, with .Manifest, .Summary and .Report available.").Default("estafette-ci-demo {{.Manifest.Tool.Version}}\n\nExported {{.Summary}} from {{.Manifest.APIHost}}").OverrideDefaultFromEnvar("PUBLISH_MESSAGE").String()
+	publishUserName          = publishCommand.Flag("user-name", "The name to commit as.").Envar("GIT_USER_NAME").String()
+	publishUserEmail         = publishCommand.Flag("user-email", "The email address to commit as.").Envar("GIT_USER_EMAIL").String()
+	publishPush              = publishCommand.Flag("push", "Push the commit to the origin.").Default("true").OverrideDefaultFromEnvar("PUBLISH_PUSH").Bool()
 )
 
 func main() {
 
 	// parse command line parameters
-	kingpin.Parse()
+	command := kingpin.Parse()
 
 	// init log format from envvar ESTAFETTE_LOG_FORMAT
 	foundation.InitLoggingFromEnv(foundation.NewApplicationInfo(appgroup, app, version, branch, revision, buildDate))
@@ -54,290 +114,223 @@ func main() {
 
 	ctx := context.Background()
 
-	span, ctx := opentracing.StartSpanFromContext(ctx, "Main")
-	defer span.Finish()
-
-	apiClient := NewApiClient(*apiBaseURL)
-
-	token, err := apiClient.GetToken(ctx, *clientID, *clientSecret)
-	handleError(closer, err)
-
-	pipelines := PipelinesListResponse{
-		Items: []*contracts.Pipeline{},
-	}
-
-	for _, p := range strings.Split(*pipelinesToExtract, ",") {
-		pipeline, err := apiClient.GetPipeline(ctx, token, p)
+	switch command {
+	case serveCommand.FullCommand():
+		err := serveMocks(*saveToDirectory, *listenAddress)
 		handleError(closer, err)
 
-		if pipeline != nil {
-
-			obfuscatePipeline(pipeline)
-
-			pipelines.Items = append(pipelines.Items, pipeline)
-
-			err = saveObjectToFile(filepath.Join("/api/pipelines", p), pipeline)
-			handleError(closer, err)
-
-			// store builds json
-			builds, err := apiClient.GetPipelineBuilds(ctx, token, p)
-			handleError(closer, err)
-			builds.Pagination.TotalPages = 1
-			builds.Pagination.TotalItems = len(builds.Items)
-
-			// http://jmoiron.net/blog/limiting-concurrency-in-go/
-			concurrency := 10
-			semaphore := make(chan bool, concurrency)
-
-			for _, b := range builds.Items {
-				obfuscateBuild(b)
-			}
-
-			err = saveObjectToFile(filepath.Join("/api/pipelines", p, "builds"), builds)
-			handleError(closer, err)
-
-			// loop builds
-			for _, b := range builds.Items {
-				// try to fill semaphore up to it's full size otherwise wait for a routine to finish
-				semaphore <- true
-
-				go func(b *contracts.Build) {
-					// lower semaphore once the routine's finished, making room for another one to start
-					defer func() { <-semaphore }()
-
-					// store build json
-					url := fmt.Sprintf("/api/pipelines/%v/builds/%v", p, b.ID)
-
-					build, err := apiClient.GetPipelineBuild(ctx, token, url)
-					handleError(closer, err)
-
-					obfuscateBuild(build)
-
-					err = saveObjectToFile(url, build)
-					handleError(closer, err)
-
-					// store build warnings json
-					url = fmt.Sprintf("/api/pipelines/%v/builds/%v/warnings", p, b.ID)
-
-					bytes, err := apiClient.GetBytesResponse(ctx, token, url)
-					handleError(closer, err)
-
-					bytes = obfuscateLog(bytes)
-
-					err = saveBytesToFile(url, bytes)
-					handleError(closer, err)
-
-					// store logs index
-					url = fmt.Sprintf("/api/pipelines/%v/builds/%v/alllogs", p, b.ID)
-					buildLogs, err := apiClient.GetPipelineBuildLogs(ctx, token, url)
-					handleError(closer, err)
-
-					err = saveObjectToFile(url, buildLogs)
-					handleError(closer, err)
-
-					if b.BuildStatus == "pending" || b.BuildStatus == "running" || b.BuildStatus == "canceling" {
-						// store build logs stream json
-						url = fmt.Sprintf("/api/pipelines/%v/builds/%v/logs.stream", p, b.ID)
-
-						bytes, err := apiClient.GetSSEResponse(ctx, token, url, 200)
-						handleError(closer, err)
-
-						bytes = obfuscateLog(bytes)
-
-						err = saveSSEBytesToFile(url, bytes)
-						handleError(closer, err)
-					} else {
-						for _, bl := range buildLogs.Items {
-							// store build logs json
-							url = fmt.Sprintf("/api/pipelines/%v/builds/%v/logsbyid/%v", p, b.ID, bl.ID)
-
-							bytes, err := apiClient.GetBytesResponse(ctx, token, url)
-							handleError(closer, err)
+	case recordCommand.FullCommand():
+		handlerOptions, err := parseHandlerOptions(*handlerDelays, *handlerContentTypes, *handlerStatusCodes)
+		handleError(closer, err)
 
-							bytes = obfuscateLog(bytes)
+		sink, err := newFixtureSink(*sinkType, *saveToDirectory, handlerOptions)
+		handleError(closer, err)
 
-							err = saveBytesToFile(url, bytes)
-							handleError(closer, err)
-						}
-					}
-				}(b)
-			}
+		sink, err = withIDRemapping(sink)
+		handleError(closer, err)
 
-			// store releases json
-			releases, err := apiClient.GetPipelineReleases(ctx, token, p)
-			handleError(closer, err)
-			releases.Pagination.TotalPages = 1
-			releases.Pagination.TotalItems = len(builds.Items)
+		obfuscator, err := NewObfuscator(*logObfuscateRegex)
+		handleError(closer, err)
 
-			for _, r := range releases.Items {
-				obfuscateRelease(r)
-			}
+		err = recordResponses(ctx, *apiBaseURL, *clientID, *clientSecret, *recordListenAddress, sink, obfuscator)
+		handleError(closer, err)
 
-			err = saveObjectToFile(filepath.Join("/api/pipelines", p, "releases"), releases)
+	case generateCommand.FullCommand():
+		var referenceTime time.Time
+		if *generateReferenceTime != "" {
+			var err error
+			referenceTime, err = time.Parse(time.RFC3339, *generateReferenceTime)
 			handleError(closer, err)
+		}
 
-			// loop releases
-			for _, r := range releases.Items {
-				// try to fill semaphore up to it's full size otherwise wait for a routine to finish
-				semaphore <- true
-
-				go func(r *contracts.Release) {
-					// lower semaphore once the routine's finished, making room for another one to start
-					defer func() { <-semaphore }()
-
-					// store release json
-					url := fmt.Sprintf("/api/pipelines/%v/releases/%v", p, r.ID)
-
-					release, err := apiClient.GetPipelineRelease(ctx, token, url)
-					handleError(closer, err)
-
-					obfuscateRelease(release)
-
-					err = saveObjectToFile(url, release)
-					handleError(closer, err)
-
-					// store logs index
-					url = fmt.Sprintf("/api/pipelines/%v/releases/%v/alllogs", p, r.ID)
-					releaseLogs, err := apiClient.GetPipelineReleaseLogs(ctx, token, url)
-					handleError(closer, err)
-
-					err = saveObjectToFile(url, releaseLogs)
-					handleError(closer, err)
+		options := GeneratorOptions{
+			Profile:           *generateProfile,
+			Seed:              *generateSeed,
+			ReferenceTime:     referenceTime,
+			Pipelines:         *generatePipelines,
+			BuildsPerPipeline: *generateBuildsPerPipeline,
+			ReleasesPerTarget: *generateReleasesPerTarget,
+			BotsPerPipeline:   *generateBotsPerPipeline,
+			LogLinesPerStep:   *generateLogLinesPerStep,
+			ListLimit:         *generateListLimit,
+			PageSize:          *generatePageSize,
+		}.withDefaults()
+		sink, err := newExportSink(newExportManifest(generatedAPIHost, generatedPipelinePaths(options.Seed, options.Pipelines), ""), *generateChecksums)
+		handleError(closer, err)
 
-					if r.ReleaseStatus == "pending" || r.ReleaseStatus == "running" || r.ReleaseStatus == "canceling" {
-						// store build logs stream json
-						url = fmt.Sprintf("/api/pipelines/%v/releases/%v/logs.stream", p, r.ID)
+		err = NewGenerator(sink, options).Run(ctx)
+		handleError(closer, err)
 
-						bytes, err := apiClient.GetSSEResponse(ctx, token, url, 200)
-						handleError(closer, err)
+	case augmentCommand.FullCommand():
+		// the augmented export keeps the origin of the export it's based on
+		source, err := readExportManifest(*augmentDirectory)
+		handleError(closer, err)
 
-						bytes = obfuscateLog(bytes)
+		// only record checksums if the export it's based on has them
+		sink, err := newExportSink(newExportManifest(source.APIHost, source.Pipelines, source.ObfuscationRulesHash), len(source.Files) > 0)
+		handleError(closer, err)
 
-						err = saveSSEBytesToFile(url, bytes)
-						handleError(closer, err)
-					} else {
-						for _, rl := range releaseLogs.Items {
-							// store release logs json
-							url = fmt.Sprintf("/api/pipelines/%v/releases/%v/logsbyid/%v", p, r.ID, rl.ID)
+		err = NewAugmenter(*augmentDirectory, sink, AugmenterOptions{
+			Seed:              *augmentSeed,
+			BuildsPerPipeline: *augmentBuildsPerPipeline,
+			ReleasesPerTarget: *augmentReleasesPerTarget,
+			BotsPerPipeline:   *augmentBotsPerPipeline,
+			PageSize:          *augmentPageSize,
+		}).Run(ctx)
+		handleError(closer, err)
 
-							bytes, err := apiClient.GetBytesResponse(ctx, token, url)
-							handleError(closer, err)
+	case diffCommand.FullCommand():
+		report, err := diffExports(*diffOldDirectory, *diffNewDirectory)
+		handleError(closer, err)
 
-							bytes = obfuscateLog(bytes)
+		err = printDiffReport(os.Stdout, report, *diffFormat)
+		handleError(closer, err)
 
-							err = saveBytesToFile(url, bytes)
-							handleError(closer, err)
-						}
-					}
-				}(r)
-			}
+	case publishCommand.FullCommand():
+		_, err := publishExport(ctx, *saveToDirectory, PublishOptions{
+			Repository:        *publishRepository,
+			Branch:            *publishBranch,
+			CheckoutDirectory: *publishCheckoutDirectory,
+			TargetDirectory:   *publishTargetDirectory,
+			Subdirectory:      *publishSubdirectory,
+			MessageTemplate:   *publishMessage,
+			UserName:          *publishUserName,
+			UserEmail:         *publishUserEmail,
+			Push:              *publishPush,
+		})
+		handleError(closer, err)
 
-			// store bots json
-			bots, err := apiClient.GetPipelineBots(ctx, token, p)
-			handleError(closer, err)
-			bots.Pagination.TotalPages = 1
-			bots.Pagination.TotalItems = len(builds.Items)
+	default:
+		obfuscator, err := NewObfuscator(*logObfuscateRegex)
+		handleError(closer, err)
 
-			for _, r := range releases.Items {
-				obfuscateRelease(r)
-			}
+		var apiClientOptions ApiClientOptions
+		if *harMode != harModeNone {
+			apiClientOptions.HarExchanges = NewHarExchanges()
+		}
 
-			err = saveObjectToFile(filepath.Join("/api/pipelines", p, "bots"), bots)
-			handleError(closer, err)
+		sink, err := newExtractSink(obfuscator, apiClientOptions.HarExchanges)
+		handleError(closer, err)
 
-			// loop bots
-			for _, b := range bots.Items {
-				// try to fill semaphore up to it's full size otherwise wait for a routine to finish
-				semaphore <- true
+		if *strictDecode || *preserveUnknownFields {
+			apiClientOptions.SchemaDrift = NewSchemaDrift()
+		}
+		if *preserveRawJSON {
+			apiClientOptions.RawResponses = NewRawResponses()
+		}
+		apiClient := NewApiClientWithOptions(*apiBaseURL, apiClientOptions)
+
+		extractor := NewExtractor(apiClient, sink, obfuscator, ExtractorOptions{
+			ClientID:              *clientID,
+			ClientSecret:          *clientSecret,
+			Pipelines:             strings.Split(*pipelinesToExtract, ","),
+			PageSize:              *pageSize,
+			SchemaDrift:           apiClientOptions.SchemaDrift,
+			PreserveUnknownFields: *preserveUnknownFields,
+			RawResponses:          apiClientOptions.RawResponses,
+		})
+
+		err = extractor.Run(ctx)
+		handleError(closer, err)
+	}
+}
 
-				go func(b *contracts.Bot) {
-					// lower semaphore once the routine's finished, making room for another one to start
-					defer func() { <-semaphore }()
+// newExtractSink returns the sink selected by the flags, combined with the HTTP Archive and WireMock sinks if enabled
+func newExtractSink(obfuscator *Obfuscator, harExchanges *HarExchanges) (FixtureSink, error) {
 
-					// store build json
-					url := fmt.Sprintf("/api/pipelines/%v/bots/%v", p, b.ID)
+	sink, err := newExportSink(newExportManifest(*apiBaseURL, strings.Split(*pipelinesToExtract, ","), obfuscator.RulesHash()), true)
+	if err != nil {
+		return nil, err
+	}
 
-					bot, err := apiClient.GetPipelineBot(ctx, token, url)
-					handleError(closer, err)
+	sinks := multiSink{sink}
 
-					obfuscateBot(bot)
+	remapper, err := newIDRemapper()
+	if err != nil {
+		return nil, err
+	}
 
-					err = saveObjectToFile(url, bot)
-					handleError(closer, err)
+	if *harMode != harModeNone {
+		// the archive gets the remapped paths, so it has to look up the exchanges by them
+		harExchanges.RemapIDs(remapper)
+		sinks = append(sinks, NewHarSink(*apiBaseURL, *harMode, *harDirectory, harExchanges))
+	}
 
-					// store logs index
-					url = fmt.Sprintf("/api/pipelines/%v/bots/%v/alllogs", p, b.ID)
-					botLogs, err := apiClient.GetPipelineBotLogs(ctx, token, url)
-					handleError(closer, err)
+	if *wireMockDirectory != "" {
+		sinks = append(sinks, NewWireMockSink(*wireMockDirectory))
+	}
 
-					err = saveObjectToFile(url, botLogs)
-					handleError(closer, err)
+	if remapper == nil {
+		return sinks, nil
+	}
 
-					if b.BotStatus == "pending" || b.BotStatus == "running" || b.BotStatus == "canceling" {
-						// store bot logs stream json
-						url = fmt.Sprintf("/api/pipelines/%v/bots/%v/logs.stream", p, b.ID)
+	return NewIDRemappingSink(sinks, remapper), nil
+}
 
-						bytes, err := apiClient.GetSSEResponse(ctx, token, url, 200)
-						handleError(closer, err)
+// withIDRemapping wraps sink to replace all ids before storing them if enabled, so every stored format gets the same ids
+func withIDRemapping(sink FixtureSink) (FixtureSink, error) {
 
-						bytes = obfuscateLog(bytes)
+	remapper, err := newIDRemapper()
+	if err != nil || remapper == nil {
+		return sink, err
+	}
 
-						err = saveSSEBytesToFile(url, bytes)
-						handleError(closer, err)
-					} else {
-						for _, bl := range botLogs.Items {
-							// store build logs json
-							url = fmt.Sprintf("/api/pipelines/%v/bots/%v/logsbyid/%v", p, b.ID, bl.ID)
+	return NewIDRemappingSink(sink, remapper), nil
+}
 
-							bytes, err := apiClient.GetBytesResponse(ctx, token, url)
-							handleError(closer, err)
+// newIDRemapper returns the remapper for the ids if enabled, or nil otherwise
+func newIDRemapper() (*IDRemapper, error) {
 
-							bytes = obfuscateLog(bytes)
+	if !*remapIDs {
+		return nil, nil
+	}
 
-							err = saveBytesToFile(url, bytes)
-							handleError(closer, err)
-						}
-					}
-				}(b)
-			}
+	if *idRemappingKey == "" {
+		log.Warn().Msg("No id remapping key set, ids will differ from those of any other run")
+	}
 
-			pipelinesSubPaths := []string{"buildbranches", "botnames", "warnings", "stats/buildsdurations", "stats/buildscpu", "stats/buildsmemory", "stats/releasesdurations", "stats/releasescpu", "stats/releasesmemory"}
-			for _, path := range pipelinesSubPaths {
-				// try to fill semaphore up to it's full size otherwise wait for a routine to finish
-				semaphore <- true
+	return NewIDRemapper(*idRemappingKey)
+}
 
-				go func(path string) {
-					// lower semaphore once the routine's finished, making room for another one to start
-					defer func() { <-semaphore }()
+// newExportSink returns the sink selected by the flags, replacing a previous export in a directory only once completed and storing manifest with it,
+// including the checksum of every file if checksums is set
+func newExportSink(manifest ExportManifest, checksums bool) (FixtureSink, error) {
 
-					url := fmt.Sprintf("/api/pipelines/%v/%v", p, path)
+	handlerOptions, err := parseHandlerOptions(*handlerDelays, *handlerContentTypes, *handlerStatusCodes)
+	if err != nil {
+		return nil, err
+	}
 
-					bytes, err := apiClient.GetBytesResponse(ctx, token, url)
-					handleError(closer, err)
+	var sink FixtureSink
+	switch *sinkType {
+	case sinkTypeMocker, sinkTypeDirectory:
+		// only replace the previous export once this one has been completed
+		sink, err = NewStagingSink(*saveToDirectory, func(directory string) (FixtureSink, error) {
+			return newFixtureSink(*sinkType, directory, handlerOptions)
+		})
+	default:
+		sink, err = newFixtureSink(*sinkType, *saveToDirectory, handlerOptions)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-					err = saveBytesToFile(url, bytes)
-					handleError(closer, err)
-				}(path)
-			}
+	return NewManifestSink(sink, manifest, checksums), nil
+}
 
-			// try to fill semaphore up to it's full size which only succeeds if all routines have finished
-			for i := 0; i < cap(semaphore); i++ {
-				semaphore <- true
-			}
-		}
+func validateAPIFlags(*kingpin.CmdClause) error {
+	if *apiBaseURL == "" || *clientID == "" || *clientSecret == "" {
+		return fmt.Errorf("flags --api-base-url, --client-id and --client-secret are required")
 	}
+	return nil
+}
 
-	if len(pipelines.Items) > 0 {
-		pipelines.Pagination = contracts.Pagination{
-			Page:       1,
-			Size:       12,
-			TotalItems: len(pipelines.Items),
-			TotalPages: 1,
-		}
-
-		err = saveObjectToFile("/api/pipelines", pipelines)
-		handleError(closer, err)
+// validateGenerateFlags rejects the archive sinks, which keep every file in memory until they're closed, for datasets that can outgrow it
+func validateGenerateFlags(*kingpin.CmdClause) error {
+	if *sinkType == sinkTypeTarGz || *sinkType == sinkTypeZip {
+		return fmt.Errorf("flag --sink %v is not supported by generate, use %v or %v and archive the directory", *sinkType, sinkTypeMocker, sinkTypeDirectory)
 	}
+	return nil
 }
 
 func handleError(jaegerCloser io.Closer, err error) {
@@ -364,36 +357,18 @@ func initJaeger(service string) io.Closer {
 	return closer
 }
 
-func saveObjectToFile(path string, object interface{}) (err error) {
+func saveObject(sink FixtureSink, path string, object interface{}) (err error) {
 
 	bytes, err := json.MarshalIndent(object, "", "  ")
 	if err != nil {
 		return
 	}
 
-	return saveBytesToFile(path, bytes)
+	return saveBytes(sink, path, bytes)
 }
 
-func saveBytesToFile(path string, bytes []byte) (err error) {
-	targetDir := filepath.Join(*saveToDirectory, path)
-	err = os.MkdirAll(targetDir, os.ModePerm)
-	if err != nil {
-		return
-	}
-
-	targetPath := filepath.Join(targetDir, "/index.json")
-	err = ioutil.WriteFile(targetPath, bytes, 0644)
-	if err != nil {
-		return
-	}
-
-	// copy GET.js to target dir
-	input, err := ioutil.ReadFile("./GET.js")
-	if err != nil {
-		return
-	}
-
-	err = ioutil.WriteFile(filepath.Join(targetDir, "/GET.js"), input, 0644)
+func saveBytes(sink FixtureSink, path string, bytes []byte) (err error) {
+	err = sink.WriteJSON(path, nil, bytes)
 	if err != nil {
 		return
 	}
@@ -403,107 +378,48 @@ func saveBytesToFile(path string, bytes []byte) (err error) {
 	return nil
 }
 
-func saveSSEBytesToFile(path string, bytes []byte) (err error) {
-	targetDir := filepath.Join(*saveToDirectory, path)
-	err = os.MkdirAll(targetDir, os.ModePerm)
-	if err != nil {
-		return
-	}
+// saveList stores the full list and, if paging is enabled, each page of its items
+func saveList(sink FixtureSink, path string, list interface{}, items interface{}, pageSize int) (err error) {
 
-	targetPath := filepath.Join(targetDir, "/index.json")
-	err = ioutil.WriteFile(targetPath, bytes, 0644)
+	err = saveObject(sink, path, list)
 	if err != nil {
 		return
 	}
 
-	// copy GET.js to target dir
-	input, err := ioutil.ReadFile("./GET-sse.js")
-	if err != nil {
-		return
-	}
-
-	err = ioutil.WriteFile(filepath.Join(targetDir, "/GET.js"), input, 0644)
-	if err != nil {
-		return
-	}
-
-	log.Info().Msgf("Fetched and saved %v", path)
-
-	return nil
+	return savePages(sink, path, items, pageSize)
 }
 
-func obfuscatePipeline(pipeline *contracts.Pipeline) {
-	for i := 0; i < len(pipeline.Commits); i++ {
-		pipeline.Commits[i].Author.Email = "me@estafette.io"
-		pipeline.Commits[i].Author.Name = "Just Me"
-		pipeline.Commits[i].Author.Username = "JustMe"
-	}
+// savePages stores each page of items next to the full list at path, if paging is enabled
+func savePages(sink FixtureSink, path string, items interface{}, pageSize int) (err error) {
 
-	for i := 0; i < len(pipeline.ReleaseTargets); i++ {
-		for j := 0; j < len(pipeline.ReleaseTargets[i].ActiveReleases); j++ {
-			for k := 0; k < len(pipeline.ReleaseTargets[i].ActiveReleases[j].Events); k++ {
-				if pipeline.ReleaseTargets[i].ActiveReleases[j].Events[k].Manual != nil {
-					pipeline.ReleaseTargets[i].ActiveReleases[j].Events[k].Manual.UserID = "me@estafette.io"
-				}
-			}
-		}
+	if pageSize <= 0 {
+		return nil
 	}
 
-	for i := 0; i < len(pipeline.Events); i++ {
-		if pipeline.Events[i].Manual != nil {
-			pipeline.Events[i].Manual.UserID = "me@estafette.io"
+	for _, page := range paginate(items, pageSize) {
+		bytes, err := json.MarshalIndent(page, "", "  ")
+		if err != nil {
+			return err
 		}
-	}
-}
-
-func obfuscateBuild(build *contracts.Build) {
-	for i := 0; i < len(build.Commits); i++ {
-		build.Commits[i].Author.Email = "me@estafette.io"
-		build.Commits[i].Author.Name = "Just Me"
-		build.Commits[i].Author.Username = "JustMe"
-	}
 
-	for i := 0; i < len(build.ReleaseTargets); i++ {
-		for j := 0; j < len(build.ReleaseTargets[i].ActiveReleases); j++ {
-			for k := 0; k < len(build.ReleaseTargets[i].ActiveReleases[j].Events); k++ {
-				if build.ReleaseTargets[i].ActiveReleases[j].Events[k].Manual != nil {
-					build.ReleaseTargets[i].ActiveReleases[j].Events[k].Manual.UserID = "me@estafette.io"
-				}
-			}
+		err = sink.WriteJSON(path, pageQuery(page.Pagination.Page, page.Pagination.Size), bytes)
+		if err != nil {
+			return err
 		}
 	}
 
-	for i := 0; i < len(build.Events); i++ {
-		if build.Events[i].Manual != nil {
-			build.Events[i].Manual.UserID = "me@estafette.io"
-		}
-	}
-}
+	log.Info().Msgf("Saved pages of %v", path)
 
-func obfuscateRelease(release *contracts.Release) {
-	for i := 0; i < len(release.Events); i++ {
-		if release.Events[i].Manual != nil {
-			release.Events[i].Manual.UserID = "me@estafette.io"
-		}
-	}
+	return nil
 }
 
-func obfuscateBot(bot *contracts.Bot) {
-	for i := 0; i < len(bot.Events); i++ {
-		if bot.Events[i].Manual != nil {
-			bot.Events[i].Manual.UserID = "me@estafette.io"
-		}
+func saveSSEBytes(sink FixtureSink, path string, bytes []byte) (err error) {
+	err = sink.WriteSSE(path, bytes)
+	if err != nil {
+		return
 	}
-}
 
-func obfuscateLog(bytes []byte) []byte {
-	re := regexp.MustCompile(`[a-z0-9-]+@[a-z0-9-]+\.iam\.gserviceaccount\.com`)
-	bytes = re.ReplaceAll(bytes, []byte("***@***.iam.gserviceaccount.com"))
-
-	if *logObfuscateRegex != "" {
-		re2 := regexp.MustCompile(*logObfuscateRegex)
-		bytes = re2.ReplaceAll(bytes, []byte("***"))
-	}
+	log.Info().Msgf("Fetched and saved %v", path)
 
-	return bytes
+	return nil
 }
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// manifestFileName is the name of the manifest, stored as plain file next to the fixtures so it isn't served as api response
+const manifestFileName = "manifest.json"
+
+// ExportManifest records what an export contains and where it came from
+type ExportManifest struct {
+	Tool                 ExportTool        `json:"tool"`
+	APIHost              string            `json:"apiHost"`
+	Pipelines            []string          `json:"pipelines"`
+	StartedAt            time.Time         `json:"startedAt"`
+	FinishedAt           time.Time         `json:"finishedAt"`
+	ObfuscationRulesHash string            `json:"obfuscationRulesHash"`
+	Counts               map[string]int    `json:"counts"`
+	Files                map[string]string `json:"files,omitempty"`
+	// Endpoints lists the paths of the stored responses outside of /api/pipelines, like /api/builds and /api/stats/mostbuilds
+	Endpoints []string `json:"endpoints,omitempty"`
+}
+
+// ExportTool identifies the build of the tool that created an export
+type ExportTool struct {
+	App       string `json:"app"`
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	Branch    string `json:"branch"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+// newExportManifest returns a manifest for an export of pipelines from the api at apiBaseURL started now
+func newExportManifest(apiBaseURL string, pipelines []string, obfuscationRulesHash string) ExportManifest {
+
+	apiHost := apiBaseURL
+	if u, err := url.Parse(apiBaseURL); err == nil && u.Host != "" {
+		apiHost = u.Host
+	}
+
+	return ExportManifest{
+		Tool: ExportTool{
+			App:       app,
+			Version:   version,
+			Revision:  revision,
+			Branch:    branch,
+			BuildDate: buildDate,
+			GoVersion: goVersion,
+		},
+		APIHost:              apiHost,
+		Pipelines:            pipelines,
+		StartedAt:            time.Now().UTC(),
+		ObfuscationRulesHash: obfuscationRulesHash,
+		Counts:               map[string]int{},
+		Files:                map[string]string{},
+	}
+}
+
+// readExportManifest reads the manifest of the export in directory, returning an empty manifest for exports without one
+func readExportManifest(directory string) (manifest ExportManifest, err error) {
+	err = readJSONFile(filepath.Join(directory, manifestFileName), &manifest)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	return
+}
+
+// manifestSink passes fixtures on to another sink while counting them and optionally recording their checksums,
+// and stores the manifest as plain file in that sink on Finalize
+type manifestSink struct {
+	sink      FixtureSink
+	mutex     sync.Mutex
+	manifest  ExportManifest
+	checksums bool
+	endpoints map[string]bool
+}
+
+// NewManifestSink returns a sink adding the manifest to the fixtures written to sink; without checksums it keeps no state per file,
+// so its memory use doesn't grow with the size of the export, but fixtures written again get counted again
+func NewManifestSink(sink FixtureSink, manifest ExportManifest, checksums bool) FixtureSink {
+	return &manifestSink{
+		sink:      sink,
+		manifest:  manifest,
+		checksums: checksums,
+		endpoints: map[string]bool{},
+	}
+}
+
+func (s *manifestSink) WriteJSON(path string, query url.Values, bytes []byte) error {
+	resourceType := resourceTypeForPath(path)
+	if isPageQuery(query) {
+		resourceType = "pages"
+	}
+	s.record(fixtureFileName(path, query), resourceType, bytes)
+	s.recordEndpoint(path)
+
+	return s.sink.WriteJSON(path, query, bytes)
+}
+
+func (s *manifestSink) WriteSSE(path string, bytes []byte) error {
+	s.record(fixtureFileName(path, nil), "streams", bytes)
+	s.recordEndpoint(path)
+
+	return s.sink.WriteSSE(path, bytes)
+}
+
+func (s *manifestSink) WriteFile(name string, bytes []byte) error {
+	return s.sink.WriteFile(name, bytes)
+}
+
+func (s *manifestSink) Finalize() error {
+
+	s.mutex.Lock()
+	s.manifest.FinishedAt = time.Now().UTC()
+	if len(s.endpoints) > 0 {
+		s.manifest.Endpoints = sortedKeys(s.endpoints)
+	}
+	bytes, err := json.MarshalIndent(s.manifest, "", "  ")
+	s.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	err = s.sink.WriteFile(manifestFileName, bytes)
+	if err != nil {
+		return err
+	}
+
+	return s.sink.Finalize()
+}
+
+func (s *manifestSink) record(name, resourceType string, bytes []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.checksums {
+		s.manifest.Counts[resourceType]++
+		return
+	}
+
+	// a fixture written again replaces the previous one
+	if _, ok := s.manifest.Files[name]; !ok {
+		s.manifest.Counts[resourceType]++
+	}
+	s.manifest.Files[name] = fmt.Sprintf("%x", sha256.Sum256(bytes))
+}
+
+// recordEndpoint lists path in the manifest if it's outside of /api/pipelines; there's only a handful of those, so they're kept
+// with or without checksums
+func (s *manifestSink) recordEndpoint(path string) {
+	if path == "/api/pipelines" || strings.HasPrefix(path, "/api/pipelines/") {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.endpoints[path] = true
+}
+
+// resourceTypeForPath returns the type of resource stored at path, for counting them in the manifest
+func resourceTypeForPath(path string) string {
+
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api/pipelines"), "/"), "/")
+
+	switch {
+	case path == "/api/builds" || path == "/api/releases":
+		return "lists"
+	case strings.HasPrefix(path, "/api/catalog"):
+		return "catalog"
+	case strings.HasPrefix(path, "/api/stats") || strings.HasPrefix(path, "/api/labels") || strings.HasPrefix(path, "/api/releasetargets") || strings.HasPrefix(path, "/api/organizations"):
+		return "insights"
+	case !strings.HasPrefix(path, "/api/pipelines"):
+		return "other"
+	case segments[0] == "":
+		return "lists"
+	case len(segments) == 3:
+		return "pipelines"
+	case len(segments) == 4 && (segments[3] == "builds" || segments[3] == "releases" || segments[3] == "bots"):
+		return "lists"
+	case len(segments) == 5 && (segments[3] == "builds" || segments[3] == "releases" || segments[3] == "bots"):
+		return segments[3]
+	case len(segments) == 7 && segments[5] == "logsbyid":
+		return "logs"
+	}
+
+	return "other"
+}
+
+// verifyManifest checks the checksum of every file listed in the manifest stored in directory, if any
+func verifyManifest(directory string) error {
+
+	bytes, err := ioutil.ReadFile(filepath.Join(directory, manifestFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var manifest ExportManifest
+	err = json.Unmarshal(bytes, &manifest)
+	if err != nil {
+		return err
+	}
+
+	for name, checksum := range manifest.Files {
+		fileBytes, err := ioutil.ReadFile(filepath.Join(directory, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+		if fmt.Sprintf("%x", sha256.Sum256(fileBytes)) != checksum {
+			return fmt.Errorf("Checksum of %v doesn't match the manifest", name)
+		}
+	}
+
+	return nil
+}
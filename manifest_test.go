@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifestSink(t *testing.T) {
+	t.Run("StoresManifestWithChecksumsAndCounts", func(t *testing.T) {
+
+		memory := NewMemorySink()
+		sink := NewManifestSink(memory, newExportManifest("https://api.estafette.io/api", []string{"github.com/estafette/estafette-ci-api"}, newTestObfuscator(t).RulesHash()), true)
+		err := sink.WriteJSON("/api/pipelines/github.com/estafette/estafette-ci-api", nil, []byte(`{}`))
+		assert.Nil(t, err)
+		err = sink.WriteJSON("/api/pipelines/github.com/estafette/estafette-ci-api/builds", nil, []byte(`{"items":[]}`))
+		assert.Nil(t, err)
+		err = sink.WriteJSON("/api/pipelines/github.com/estafette/estafette-ci-api/builds/5", nil, []byte(`{}`))
+		assert.Nil(t, err)
+		err = sink.WriteJSON("/api/stats/mostbuilds", nil, []byte(`{"items":[]}`))
+		assert.Nil(t, err)
+
+		// act
+		err = sink.Finalize()
+
+		assert.Nil(t, err)
+		var manifest ExportManifest
+		err = json.Unmarshal(memory.Files()["manifest.json"], &manifest)
+		assert.Nil(t, err)
+		assert.Equal(t, "api.estafette.io", manifest.APIHost)
+		assert.Equal(t, []string{"github.com/estafette/estafette-ci-api"}, manifest.Pipelines)
+		assert.Equal(t, map[string]int{"pipelines": 1, "lists": 1, "builds": 1, "insights": 1}, manifest.Counts)
+		assert.Equal(t, []string{"/api/stats/mostbuilds"}, manifest.Endpoints)
+		assert.Equal(t, "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", manifest.Files["api/pipelines/github.com/estafette/estafette-ci-api/index.json"])
+		assert.False(t, manifest.FinishedAt.Before(manifest.StartedAt))
+		assert.NotEmpty(t, manifest.ObfuscationRulesHash)
+	})
+
+	t.Run("StoresManifestAsPlainFileWithoutMockerHandler", func(t *testing.T) {
+
+		directory, err := ioutil.TempDir("", "manifest")
+		assert.Nil(t, err)
+		defer os.RemoveAll(directory)
+		mocker, err := NewMockerSink(directory, nil)
+		assert.Nil(t, err)
+		sink := NewManifestSink(mocker, newExportManifest("https://api.estafette.io/api", nil, ""), true)
+
+		// act
+		err = sink.Finalize()
+
+		assert.Nil(t, err)
+		assert.FileExists(t, filepath.Join(directory, "manifest.json"))
+		assert.NoDirExists(t, filepath.Join(directory, "manifest"))
+	})
+
+	t.Run("KeepsStateIndependentOfBuildCountWithoutChecksums", func(t *testing.T) {
+
+		manifests := []ExportManifest{}
+		for _, builds := range []int{10, 100} {
+			sink := NewManifestSink(discardSink{}, newExportManifest(generatedAPIHost, nil, ""), false)
+			generator := NewGenerator(sink, GeneratorOptions{Seed: 1, ReferenceTime: testReferenceTime, Pipelines: 2, BuildsPerPipeline: builds, ReleasesPerTarget: 1, BotsPerPipeline: 1})
+
+			// act
+			err := generator.Run(context.Background())
+
+			assert.Nil(t, err)
+			manifests = append(manifests, sink.(*manifestSink).manifest)
+		}
+
+		for _, manifest := range manifests {
+			assert.Empty(t, manifest.Files)
+		}
+		assert.Equal(t, 20, manifests[0].Counts["builds"])
+		assert.Equal(t, 200, manifests[1].Counts["builds"])
+		assert.Equal(t, len(manifests[0].Counts), len(manifests[1].Counts))
+	})
+}
+
+// discardSink drops all fixtures written to it
+type discardSink struct{}
+
+func (discardSink) WriteJSON(path string, query url.Values, bytes []byte) error { return nil }
+func (discardSink) WriteSSE(path string, bytes []byte) error                    { return nil }
+func (discardSink) WriteFile(name string, bytes []byte) error                   { return nil }
+func (discardSink) Finalize() error                                             { return nil }
+
+func TestVerifyManifest(t *testing.T) {
+	t.Run("ReturnsErrorForTamperedFile", func(t *testing.T) {
+
+		directory, err := ioutil.TempDir("", "mocks")
+		assert.Nil(t, err)
+		defer os.RemoveAll(directory)
+		sink := NewManifestSink(NewDirectorySink(directory), newExportManifest("https://api.estafette.io", []string{}, newTestObfuscator(t).RulesHash()), true)
+		err = sink.WriteJSON("/api/pipelines", nil, []byte(`{"items":[]}`))
+		assert.Nil(t, err)
+		err = sink.Finalize()
+		assert.Nil(t, err)
+		err = ioutil.WriteFile(filepath.Join(directory, "api/pipelines/index.json"), []byte(`{"items":[1]}`), 0644)
+		assert.Nil(t, err)
+
+		// act
+		err = verifyManifest(directory)
+
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+	foundation "github.com/estafette/estafette-foundation"
+)
+
+var mockFilterParameters = []string{"filter[status]", "filter[since]", "filter[labels]", "filter[search]"}
+
+// rawListResponse keeps list items as raw json so filtering doesn't drop any fields
+type rawListResponse struct {
+	Items      []json.RawMessage    `json:"items"`
+	Pagination contracts.Pagination `json:"pagination"`
+}
+
+// filterableItem holds the fields of pipelines, builds, releases and bots the filters act on
+type filterableItem struct {
+	RepoName      string            `json:"repoName"`
+	Name          string            `json:"name"`
+	BuildStatus   string            `json:"buildStatus"`
+	ReleaseStatus string            `json:"releaseStatus"`
+	BotStatus     string            `json:"botStatus"`
+	Labels        []contracts.Label `json:"labels"`
+	InsertedAt    *time.Time        `json:"insertedAt"`
+}
+
+func (i *filterableItem) status() string {
+	switch {
+	case i.BuildStatus != "":
+		return i.BuildStatus
+	case i.ReleaseStatus != "":
+		return i.ReleaseStatus
+	}
+	return i.BotStatus
+}
+
+// hasMockFilters returns true if any of the filters the api supports is set in the query
+func hasMockFilters(query url.Values) bool {
+	for _, p := range mockFilterParameters {
+		if len(query[p]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// filterListResponse applies the filter[status], filter[since], filter[labels] and filter[search] query parameters to a stored list;
+// filter[since] only applies to lists of items with an insertedAt, like builds, so it leaves counts like mostbuilds as they are
+func filterListResponse(list rawListResponse, query url.Values, now time.Time) (filtered rawListResponse, err error) {
+
+	statuses := splitQueryValues(query["filter[status]"])
+	labels := splitQueryValues(query["filter[labels]"])
+	search := query.Get("filter[search]")
+	since := sinceAsTime(query.Get("filter[since]"), now)
+
+	items := make([]filterableItem, len(list.Items))
+	hasInsertedAt := false
+	for i, rawItem := range list.Items {
+		err = json.Unmarshal(rawItem, &items[i])
+		if err != nil {
+			return
+		}
+		hasInsertedAt = hasInsertedAt || items[i].InsertedAt != nil
+	}
+	if !hasInsertedAt {
+		since = time.Time{}
+	}
+
+	filtered.Items = []json.RawMessage{}
+	for i, rawItem := range list.Items {
+		item := items[i]
+
+		if len(statuses) > 0 && !foundation.StringArrayContains(statuses, item.status()) {
+			continue
+		}
+		if !since.IsZero() && (item.InsertedAt == nil || item.InsertedAt.Before(since)) {
+			continue
+		}
+		if !hasAllLabels(item.Labels, labels) {
+			continue
+		}
+		if search != "" && !strings.Contains(item.RepoName, search) && !strings.Contains(item.Name, search) {
+			continue
+		}
+
+		filtered.Items = append(filtered.Items, rawItem)
+	}
+
+	filtered.Pagination = contracts.Pagination{
+		Page:       1,
+		Size:       len(filtered.Items),
+		TotalPages: 1,
+		TotalItems: len(filtered.Items),
+	}
+
+	return filtered, nil
+}
+
+// sinceAsTime converts the filter[since] values used by the web app into a time, the zero time meaning eternity
+func sinceAsTime(since string, now time.Time) time.Time {
+	switch since {
+	case "1h":
+		return now.Add(-1 * time.Hour)
+	case "1d":
+		return now.AddDate(0, 0, -1)
+	case "1w":
+		return now.AddDate(0, 0, -7)
+	case "1m":
+		return now.AddDate(0, -1, 0)
+	case "1y":
+		return now.AddDate(-1, 0, 0)
+	}
+
+	return time.Time{}
+}
+
+func hasAllLabels(itemLabels []contracts.Label, labelFilters []string) bool {
+	for _, f := range labelFilters {
+		keyValue := strings.SplitN(f, "=", 2)
+		if len(keyValue) != 2 {
+			continue
+		}
+
+		found := false
+		for _, l := range itemLabels {
+			if l.Key == keyValue[0] && l.Value == keyValue[1] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func splitQueryValues(values []string) (splitValues []string) {
+	for _, v := range values {
+		for _, vv := range strings.Split(v, ",") {
+			if vv != "" {
+				splitValues = append(splitValues, vv)
+			}
+		}
+	}
+	return
+}
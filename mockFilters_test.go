@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterListResponse(t *testing.T) {
+	t.Run("ReturnsItemsInsertedSinceFilter", func(t *testing.T) {
+
+		now := time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC)
+		list := rawListResponse{
+			Items: []json.RawMessage{
+				json.RawMessage(`{"id":"1","insertedAt":"2020-06-15T08:00:00Z"}`),
+				json.RawMessage(`{"id":"2","insertedAt":"2020-06-01T08:00:00Z"}`),
+			},
+		}
+		query := url.Values{"filter[since]": []string{"1d"}}
+
+		// act
+		filtered, err := filterListResponse(list, query, now)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(filtered.Items))
+		assert.Equal(t, `{"id":"1","insertedAt":"2020-06-15T08:00:00Z"}`, string(filtered.Items[0]))
+	})
+
+	t.Run("IgnoresSinceFilterForItemsWithoutInsertedAt", func(t *testing.T) {
+
+		now := time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC)
+		list := rawListResponse{
+			Items: []json.RawMessage{
+				json.RawMessage(`{"repoSource":"github.com","repoOwner":"estafette","repoName":"estafette-ci-api","nrRecords":30}`),
+				json.RawMessage(`{"repoSource":"github.com","repoOwner":"estafette","repoName":"estafette-ci-web","nrRecords":10}`),
+			},
+		}
+		query := url.Values{"filter[since]": []string{"1w"}}
+
+		// act
+		filtered, err := filterListResponse(list, query, now)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(filtered.Items))
+	})
+
+	t.Run("ReturnsItemsHavingAllLabels", func(t *testing.T) {
+
+		list := rawListResponse{
+			Items: []json.RawMessage{
+				json.RawMessage(`{"id":"1","labels":[{"key":"team","value":"estafette-team"},{"key":"language","value":"golang"}]}`),
+				json.RawMessage(`{"id":"2","labels":[{"key":"team","value":"estafette-team"}]}`),
+			},
+		}
+		query := url.Values{"filter[labels]": []string{"team=estafette-team", "language=golang"}}
+
+		// act
+		filtered, err := filterListResponse(list, query, time.Now())
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(filtered.Items))
+		assert.Equal(t, 1, filtered.Pagination.TotalItems)
+	})
+
+	t.Run("ReturnsItemsMatchingAnyOfCommaSeparatedStatuses", func(t *testing.T) {
+
+		list := rawListResponse{
+			Items: []json.RawMessage{
+				json.RawMessage(`{"id":"1","releaseStatus":"succeeded"}`),
+				json.RawMessage(`{"id":"2","releaseStatus":"running"}`),
+				json.RawMessage(`{"id":"3","releaseStatus":"failed"}`),
+			},
+		}
+		query := url.Values{"filter[status]": []string{"running,failed"}}
+
+		// act
+		filtered, err := filterListResponse(list, query, time.Now())
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(filtered.Items))
+	})
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+	"github.com/rs/zerolog/log"
+)
+
+// serveMocks serves the stored responses in the same way connect-api-mocker serves them to the web app
+func serveMocks(directory, listenAddress string) error {
+	log.Info().Msgf("Serving mock api from %v on %v", directory, listenAddress)
+
+	return http.ListenAndServe(listenAddress, NewMockServer(directory))
+}
+
+// NewMockServer returns an http.Handler serving the responses stored in directory
+func NewMockServer(directory string) http.Handler {
+	return &mockServer{
+		directory: directory,
+		now:       time.Now,
+	}
+}
+
+type mockServer struct {
+	directory string
+	now       func() time.Time
+}
+
+func (s *mockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	targetDir := filepath.Join(s.directory, filepath.FromSlash(path.Clean("/"+r.URL.Path)))
+	targetPath := filepath.Join(targetDir, "index.json")
+
+	if hasMockFilters(r.URL.Query()) {
+		s.serveFilteredList(w, r, targetPath)
+		return
+	}
+
+	// serve a single page if the list has been stored in pages
+	pageNumber, pageNumberErr := strconv.Atoi(r.URL.Query().Get("page[number]"))
+	pageSize, pageSizeErr := strconv.Atoi(r.URL.Query().Get("page[size]"))
+	if pageNumberErr == nil && pageSizeErr == nil {
+		pagePath := filepath.Join(targetDir, pageFileName(pageNumber, pageSize))
+		if _, err := os.Stat(pagePath); err == nil {
+			targetPath = pagePath
+		}
+	}
+
+	if _, err := os.Stat(targetPath); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, ".stream") {
+		w.Header().Set("Content-Type", "text/event-stream")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	http.ServeFile(w, r, targetPath)
+}
+
+// serveFilteredList applies the filters in the query to the stored list and pages the remaining items on the fly
+func (s *mockServer) serveFilteredList(w http.ResponseWriter, r *http.Request, targetPath string) {
+
+	bytes, err := ioutil.ReadFile(targetPath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var list rawListResponse
+	err = json.Unmarshal(bytes, &list)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed unmarshalling list %v for filtering", targetPath)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	list, err = filterListResponse(list, r.URL.Query(), s.now())
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed filtering list %v", targetPath)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var response interface{} = list
+	pageNumber, pageNumberErr := strconv.Atoi(r.URL.Query().Get("page[number]"))
+	pageSize, pageSizeErr := strconv.Atoi(r.URL.Query().Get("page[size]"))
+	if pageNumberErr == nil && pageSizeErr == nil && pageNumber > 0 && pageSize > 0 {
+		pages := paginate(list.Items, pageSize)
+		if pageNumber <= len(pages) {
+			response = pages[pageNumber-1]
+		} else {
+			response = listPage{
+				Items:      []json.RawMessage{},
+				Pagination: contracts.Pagination{Page: pageNumber, Size: pageSize, TotalPages: len(pages), TotalItems: len(list.Items)},
+			}
+		}
+	}
+
+	bytes, err = json.Marshal(response)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytes)
+}
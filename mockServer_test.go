@@ -0,0 +1,167 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockServer(t *testing.T) {
+	t.Run("ReturnsIndexForPath", func(t *testing.T) {
+
+		directory := createMockDirectory(t, map[string]string{
+			"api/pipelines/index.json": `{"items":[]}`,
+		})
+		defer os.RemoveAll(directory)
+
+		server := NewMockServer(directory)
+		request := httptest.NewRequest("GET", "/api/pipelines", nil)
+		recorder := httptest.NewRecorder()
+
+		// act
+		server.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, `{"items":[]}`, recorder.Body.String())
+	})
+
+	t.Run("ReturnsPageForPageQueryParameters", func(t *testing.T) {
+
+		directory := createMockDirectory(t, map[string]string{
+			"api/pipelines/index.json":               `{"items":[1,2,3]}`,
+			"api/pipelines/page-2-size-2.json":       `{"items":[3]}`,
+			"api/pipelines/page-1-size-2.json":       `{"items":[1,2]}`,
+			"api/pipelines/other/page-2-size-2.json": `{"items":[]}`,
+		})
+		defer os.RemoveAll(directory)
+
+		server := NewMockServer(directory)
+		request := httptest.NewRequest("GET", "/api/pipelines?page[number]=2&page[size]=2", nil)
+		recorder := httptest.NewRecorder()
+
+		// act
+		server.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `{"items":[3]}`, recorder.Body.String())
+	})
+
+	t.Run("FallsBackToIndexForUnknownPage", func(t *testing.T) {
+
+		directory := createMockDirectory(t, map[string]string{
+			"api/pipelines/index.json": `{"items":[1,2,3]}`,
+		})
+		defer os.RemoveAll(directory)
+
+		server := NewMockServer(directory)
+		request := httptest.NewRequest("GET", "/api/pipelines?page[number]=1&page[size]=12", nil)
+		recorder := httptest.NewRecorder()
+
+		// act
+		server.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `{"items":[1,2,3]}`, recorder.Body.String())
+	})
+
+	t.Run("ReturnsItemsMatchingStatusFilter", func(t *testing.T) {
+
+		directory := createMockDirectory(t, map[string]string{
+			"api/pipelines/index.json": `{"items":[{"repoName":"a","buildStatus":"succeeded"},{"repoName":"b","buildStatus":"failed"}]}`,
+		})
+		defer os.RemoveAll(directory)
+
+		server := NewMockServer(directory)
+		request := httptest.NewRequest("GET", "/api/pipelines?filter[status]=failed&filter[since]=eternity", nil)
+		recorder := httptest.NewRecorder()
+
+		// act
+		server.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"items":[{"repoName":"b","buildStatus":"failed"}],"pagination":{"page":1,"size":1,"totalPages":1,"totalItems":1}}`, recorder.Body.String())
+	})
+
+	t.Run("ReturnsPageOfFilteredItems", func(t *testing.T) {
+
+		directory := createMockDirectory(t, map[string]string{
+			"api/pipelines/index.json": `{"items":[{"repoName":"estafette-ci-api"},{"repoName":"estafette-ci-web"},{"repoName":"other"}]}`,
+		})
+		defer os.RemoveAll(directory)
+
+		server := NewMockServer(directory)
+		request := httptest.NewRequest("GET", "/api/pipelines?filter[search]=estafette&page[number]=2&page[size]=1", nil)
+		recorder := httptest.NewRecorder()
+
+		// act
+		server.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"items":[{"repoName":"estafette-ci-web"}],"pagination":{"page":2,"size":1,"totalPages":2,"totalItems":2}}`, recorder.Body.String())
+	})
+
+	t.Run("ReturnsNotFoundForUnknownPath", func(t *testing.T) {
+
+		directory := createMockDirectory(t, map[string]string{})
+		defer os.RemoveAll(directory)
+
+		server := NewMockServer(directory)
+		request := httptest.NewRequest("GET", "/api/pipelines", nil)
+		recorder := httptest.NewRecorder()
+
+		// act
+		server.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
+
+func TestPaginate(t *testing.T) {
+	t.Run("SplitsItemsIntoPages", func(t *testing.T) {
+
+		items := []int{1, 2, 3, 4, 5}
+
+		// act
+		pages := paginate(items, 2)
+
+		assert.Equal(t, 3, len(pages))
+		assert.Equal(t, []int{5}, pages[2].Items)
+		assert.Equal(t, 3, pages[2].Pagination.Page)
+		assert.Equal(t, 2, pages[2].Pagination.Size)
+		assert.Equal(t, 3, pages[2].Pagination.TotalPages)
+		assert.Equal(t, 5, pages[2].Pagination.TotalItems)
+	})
+
+	t.Run("ReturnsSingleEmptyPageForNoItems", func(t *testing.T) {
+
+		items := []int{}
+
+		// act
+		pages := paginate(items, 2)
+
+		assert.Equal(t, 1, len(pages))
+		assert.Equal(t, []int{}, pages[0].Items)
+		assert.Equal(t, 0, pages[0].Pagination.TotalPages)
+	})
+}
+
+func createMockDirectory(t *testing.T, files map[string]string) string {
+	directory, err := ioutil.TempDir("", "mocks")
+	assert.Nil(t, err)
+
+	for path, content := range files {
+		targetPath := filepath.Join(directory, filepath.FromSlash(path))
+		err = os.MkdirAll(filepath.Dir(targetPath), os.ModePerm)
+		assert.Nil(t, err)
+		err = ioutil.WriteFile(targetPath, []byte(content), 0644)
+		assert.Nil(t, err)
+	}
+
+	return directory
+}
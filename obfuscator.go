@@ -0,0 +1,340 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+)
+
+const (
+	obfuscatedEmail          = "me@estafette.io"
+	obfuscatedName           = "Just Me"
+	obfuscatedUsername       = "JustMe"
+	emailRegex               = `[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`
+	serviceAccountRegex      = `[a-z0-9-]+@[a-z0-9-]+\.iam\.gserviceaccount\.com`
+	obfuscatedServiceAccount = "***@***.iam.gserviceaccount.com"
+	obfuscatedLogMatch       = "***"
+)
+
+// Obfuscator masks personal data and secrets in api responses before they get stored
+type Obfuscator struct {
+	logObfuscateRegex string
+	emails            *regexp.Regexp
+	serviceAccounts   *regexp.Regexp
+	logMatches        *regexp.Regexp
+}
+
+// NewObfuscator returns an Obfuscator that masks matches of logObfuscateRegex in logs on top of the built-in rules, if set
+func NewObfuscator(logObfuscateRegex string) (*Obfuscator, error) {
+
+	o := &Obfuscator{
+		logObfuscateRegex: logObfuscateRegex,
+		emails:            regexp.MustCompile(emailRegex),
+		serviceAccounts:   regexp.MustCompile(serviceAccountRegex),
+	}
+
+	if logObfuscateRegex != "" {
+		logMatches, err := regexp.Compile(logObfuscateRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log obfuscation regex: %w", err)
+		}
+		o.logMatches = logMatches
+	}
+
+	return o, nil
+}
+
+// RulesHash returns a hash over all obfuscation rules, to tell whether two exports got obfuscated the same way
+func (o *Obfuscator) RulesHash() string {
+	rules := strings.Join([]string{obfuscatedEmail, obfuscatedName, obfuscatedUsername, emailRegex, serviceAccountRegex, obfuscatedServiceAccount, o.logObfuscateRegex, obfuscatedLogMatch}, "\n")
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(rules)))
+}
+
+// Pipeline obfuscates the commit authors, recent committers and releasers and the users of manual triggers of pipeline
+func (o *Obfuscator) Pipeline(pipeline *contracts.Pipeline) {
+	for i := 0; i < len(pipeline.Commits); i++ {
+		pipeline.Commits[i].Author.Email = obfuscatedEmail
+		pipeline.Commits[i].Author.Name = obfuscatedName
+		pipeline.Commits[i].Author.Username = obfuscatedUsername
+	}
+
+	for i := 0; i < len(pipeline.ReleaseTargets); i++ {
+		for j := 0; j < len(pipeline.ReleaseTargets[i].ActiveReleases); j++ {
+			for k := 0; k < len(pipeline.ReleaseTargets[i].ActiveReleases[j].Events); k++ {
+				if pipeline.ReleaseTargets[i].ActiveReleases[j].Events[k].Manual != nil {
+					pipeline.ReleaseTargets[i].ActiveReleases[j].Events[k].Manual.UserID = obfuscatedEmail
+				}
+			}
+		}
+	}
+
+	for i := 0; i < len(pipeline.Events); i++ {
+		if pipeline.Events[i].Manual != nil {
+			pipeline.Events[i].Manual.UserID = obfuscatedEmail
+		}
+	}
+
+	for i := 0; i < len(pipeline.RecentCommitters); i++ {
+		pipeline.RecentCommitters[i] = obfuscatedEmail
+	}
+
+	for i := 0; i < len(pipeline.RecentReleasers); i++ {
+		pipeline.RecentReleasers[i] = obfuscatedEmail
+	}
+}
+
+// Build obfuscates the commit authors and the users of manual triggers of build
+func (o *Obfuscator) Build(build *contracts.Build) {
+	for i := 0; i < len(build.Commits); i++ {
+		build.Commits[i].Author.Email = obfuscatedEmail
+		build.Commits[i].Author.Name = obfuscatedName
+		build.Commits[i].Author.Username = obfuscatedUsername
+	}
+
+	for i := 0; i < len(build.ReleaseTargets); i++ {
+		for j := 0; j < len(build.ReleaseTargets[i].ActiveReleases); j++ {
+			for k := 0; k < len(build.ReleaseTargets[i].ActiveReleases[j].Events); k++ {
+				if build.ReleaseTargets[i].ActiveReleases[j].Events[k].Manual != nil {
+					build.ReleaseTargets[i].ActiveReleases[j].Events[k].Manual.UserID = obfuscatedEmail
+				}
+			}
+		}
+	}
+
+	for i := 0; i < len(build.Events); i++ {
+		if build.Events[i].Manual != nil {
+			build.Events[i].Manual.UserID = obfuscatedEmail
+		}
+	}
+}
+
+// Release obfuscates the users of manual triggers of release
+func (o *Obfuscator) Release(release *contracts.Release) {
+	for i := 0; i < len(release.Events); i++ {
+		if release.Events[i].Manual != nil {
+			release.Events[i].Manual.UserID = obfuscatedEmail
+		}
+	}
+}
+
+// Bot obfuscates the users of manual triggers of bot
+func (o *Obfuscator) Bot(bot *contracts.Bot) {
+	for i := 0; i < len(bot.Events); i++ {
+		if bot.Events[i].Manual != nil {
+			bot.Events[i].Manual.UserID = obfuscatedEmail
+		}
+	}
+}
+
+// CatalogEntity obfuscates the labels and metadata of entity, whose nested values can hold anything like owner emails
+func (o *Obfuscator) CatalogEntity(entity *contracts.CatalogEntity) {
+	for k, v := range entity.Metadata {
+		entity.Metadata[k] = o.Value(v)
+	}
+	for i := range entity.Labels {
+		entity.Labels[i].Value = o.unknownString(entity.Labels[i].Value)
+	}
+}
+
+// Warnings applies the log obfuscation to the message of each of warnings
+func (o *Obfuscator) Warnings(warnings []*contracts.Warning) {
+	for _, w := range warnings {
+		if w != nil {
+			w.Message = string(o.Log([]byte(w.Message)))
+		}
+	}
+}
+
+// Value obfuscates a decoded json value whose meaning is unknown, like a field the contracts don't know about,
+// masking service accounts and log matches first and any remaining email address after that
+func (o *Obfuscator) Value(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return o.unknownString(v)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = o.Value(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = o.Value(item)
+		}
+	}
+	return value
+}
+
+func (o *Obfuscator) unknownString(value string) string {
+	return o.emails.ReplaceAllString(string(o.Log([]byte(value))), obfuscatedEmail)
+}
+
+// Log masks service accounts and, if set, matches of the log obfuscation regex in bytes
+func (o *Obfuscator) Log(bytes []byte) []byte {
+	bytes = o.serviceAccounts.ReplaceAll(bytes, []byte(obfuscatedServiceAccount))
+
+	if o.logMatches != nil {
+		bytes = o.logMatches.ReplaceAll(bytes, []byte(obfuscatedLogMatch))
+	}
+
+	return bytes
+}
+
+// Response applies the obfuscation for the resource type served at path to the raw response, keeping its structure; logs get
+// the log obfuscation and other json responses the obfuscation for unknown values
+func (o *Obfuscator) Response(path string, data []byte) ([]byte, error) {
+
+	var object interface{}
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api/pipelines"), "/"), "/")
+
+	switch {
+	case path == "/api/builds":
+		var response PipelineBuildsListResponse
+		err := json.Unmarshal(data, &response)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range response.Items {
+			o.Build(b)
+		}
+		object = response
+
+	case path == "/api/releases":
+		var response PipelineReleasesListResponse
+		err := json.Unmarshal(data, &response)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range response.Items {
+			o.Release(r)
+		}
+		object = response
+
+	case path == "/api/catalog/entities":
+		var response CatalogEntitiesListResponse
+		err := json.Unmarshal(data, &response)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range response.Items {
+			o.CatalogEntity(e)
+		}
+		object = response
+
+	case !strings.HasPrefix(path, "/api/pipelines"):
+		return o.unknownResponse(data)
+
+	case segments[0] == "":
+		var response PipelinesListResponse
+		err := json.Unmarshal(data, &response)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range response.Items {
+			o.Pipeline(p)
+		}
+		object = response
+
+	case len(segments) == 3:
+		var pipeline *contracts.Pipeline
+		err := json.Unmarshal(data, &pipeline)
+		if err != nil || pipeline == nil {
+			return data, err
+		}
+		o.Pipeline(pipeline)
+		object = pipeline
+
+	case len(segments) == 4 && segments[3] == "builds":
+		var response PipelineBuildsListResponse
+		err := json.Unmarshal(data, &response)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range response.Items {
+			o.Build(b)
+		}
+		object = response
+
+	case len(segments) == 5 && segments[3] == "builds":
+		var build *contracts.Build
+		err := json.Unmarshal(data, &build)
+		if err != nil || build == nil {
+			return data, err
+		}
+		o.Build(build)
+		object = build
+
+	case len(segments) == 4 && segments[3] == "releases":
+		var response PipelineReleasesListResponse
+		err := json.Unmarshal(data, &response)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range response.Items {
+			o.Release(r)
+		}
+		object = response
+
+	case len(segments) == 5 && segments[3] == "releases":
+		var release *contracts.Release
+		err := json.Unmarshal(data, &release)
+		if err != nil || release == nil {
+			return data, err
+		}
+		o.Release(release)
+		object = release
+
+	case len(segments) == 4 && segments[3] == "bots":
+		var response PipelineBotsListResponse
+		err := json.Unmarshal(data, &response)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range response.Items {
+			o.Bot(b)
+		}
+		object = response
+
+	case len(segments) == 5 && segments[3] == "bots":
+		var bot *contracts.Bot
+		err := json.Unmarshal(data, &bot)
+		if err != nil || bot == nil {
+			return data, err
+		}
+		o.Bot(bot)
+		object = bot
+
+	case len(segments) >= 6 && (segments[5] == "logs" || segments[5] == "alllogs" || segments[5] == "logsbyid"):
+		return o.Log(data), nil
+
+	default:
+		return o.unknownResponse(data)
+	}
+
+	obfuscated, ok, err := obfuscateRawJSON(data, object, o)
+	if err != nil || ok {
+		return obfuscated, err
+	}
+
+	return json.MarshalIndent(object, "", "  ")
+}
+
+// unknownResponse applies the obfuscation for unknown values to every value of a json response without a known type, keeping its
+// structure, or the log obfuscation if it isn't json
+func (o *Obfuscator) unknownResponse(data []byte) ([]byte, error) {
+
+	var value interface{}
+	if json.Unmarshal(data, &value) != nil || value == nil {
+		return o.Log(data), nil
+	}
+	object := o.Value(value)
+
+	obfuscated, ok, err := obfuscateRawJSON(data, object, o)
+	if err != nil || ok {
+		return obfuscated, err
+	}
+
+	return json.MarshalIndent(object, "", "  ")
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+// testLogObfuscateRegex masks the token in testdata/obfuscation/log.txt the way --log-obfuscate-regex would
+const testLogObfuscateRegex = `s3cr3t-[a-z0-9-]+`
+
+func TestObfuscatorGolden(t *testing.T) {
+
+	obfuscator, err := NewObfuscator(testLogObfuscateRegex)
+	assert.Nil(t, err)
+
+	testCases := []struct {
+		name      string
+		object    interface{}
+		obfuscate func(object interface{})
+	}{
+		{"pipeline", &contracts.Pipeline{}, func(object interface{}) { obfuscator.Pipeline(object.(*contracts.Pipeline)) }},
+		{"build", &contracts.Build{}, func(object interface{}) { obfuscator.Build(object.(*contracts.Build)) }},
+		{"release", &contracts.Release{}, func(object interface{}) { obfuscator.Release(object.(*contracts.Release)) }},
+		{"bot", &contracts.Bot{}, func(object interface{}) { obfuscator.Bot(object.(*contracts.Bot)) }},
+		{"catalogentity", &contracts.CatalogEntity{}, func(object interface{}) { obfuscator.CatalogEntity(object.(*contracts.CatalogEntity)) }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			input, err := ioutil.ReadFile(filepath.Join("testdata", "obfuscation", tc.name+".json"))
+			assert.Nil(t, err)
+			err = json.Unmarshal(input, tc.object)
+			assert.Nil(t, err)
+
+			// act
+			tc.obfuscate(tc.object)
+
+			output, err := json.MarshalIndent(tc.object, "", "  ")
+			assert.Nil(t, err)
+			assertGolden(t, filepath.Join("testdata", "obfuscation", tc.name+".golden.json"), output)
+		})
+	}
+
+	t.Run("log", func(t *testing.T) {
+
+		input, err := ioutil.ReadFile(filepath.Join("testdata", "obfuscation", "log.txt"))
+		assert.Nil(t, err)
+
+		// act
+		output := obfuscator.Log(input)
+
+		assertGolden(t, filepath.Join("testdata", "obfuscation", "log.golden.txt"), output)
+	})
+}
+
+// assertGolden compares actual with the content of the golden file, or overwrites the golden file when running with -update
+func assertGolden(t *testing.T, goldenPath string, actual []byte) {
+
+	if *updateGolden {
+		err := ioutil.WriteFile(goldenPath, actual, 0644)
+		assert.Nil(t, err)
+		return
+	}
+
+	expected, err := ioutil.ReadFile(goldenPath)
+	if assert.Nil(t, err, "run go test with -update to create %v", goldenPath) {
+		assert.Equal(t, string(expected), string(actual))
+	}
+}
+
+// personalFieldRegex matches the json path of fields that look like they hold personal data
+var personalFieldRegex = regexp.MustCompile(`(?i)(email|user|author|committer|releaser|login)[a-z]*(\[\])?$|author\.[a-z]+$`)
+
+// nonPersonalFields lists json paths matching personalFieldRegex that are known not to hold personal data
+var nonPersonalFields = map[string]bool{}
+
+func TestObfuscatorCoversPersonalFields(t *testing.T) {
+
+	obfuscator, err := NewObfuscator("")
+	assert.Nil(t, err)
+
+	testCases := []struct {
+		object    interface{}
+		obfuscate func(object interface{})
+	}{
+		{&contracts.Pipeline{}, func(object interface{}) { obfuscator.Pipeline(object.(*contracts.Pipeline)) }},
+		{&contracts.Build{}, func(object interface{}) { obfuscator.Build(object.(*contracts.Build)) }},
+		{&contracts.Release{}, func(object interface{}) { obfuscator.Release(object.(*contracts.Release)) }},
+		{&contracts.Bot{}, func(object interface{}) { obfuscator.Bot(object.(*contracts.Bot)) }},
+	}
+
+	for _, tc := range testCases {
+		value := reflect.ValueOf(tc.object).Elem()
+		t.Run(value.Type().Name(), func(t *testing.T) {
+
+			// set every string field to its own json path, so fields left untouched by the obfuscation can be told apart
+			fillStrings(t, value, value.Type().Name(), 0, map[reflect.Type]bool{})
+
+			// act
+			tc.obfuscate(tc.object)
+
+			values := map[string]string{}
+			collectStrings(value, value.Type().Name(), 0, values)
+			assert.NotEmpty(t, values)
+			for path, s := range values {
+				if !personalFieldRegex.MatchString(path) || nonPersonalFields[path] {
+					continue
+				}
+				assert.NotEqual(t, path, s, "field %v looks like personal data, obfuscate it or add it to nonPersonalFields", path)
+			}
+		})
+	}
+}
+
+// maxFillDepth fails the test for types nested deeper than any of the contracts, rather than leaving their fields unchecked
+const maxFillDepth = 20
+
+// stringType is the type set in interface values by fillStrings
+var stringType = reflect.TypeOf("")
+
+// fillStrings allocates every pointer, gives every slice and map a single element and sets every string, including those held by
+// interface values, to its json path; pointers to a type that's already being filled, like nested stages, are left nil, since
+// its fields get filled by the outer one
+func fillStrings(t *testing.T, value reflect.Value, path string, depth int, filling map[reflect.Type]bool) {
+
+	if depth > maxFillDepth {
+		t.Fatalf("Filling %v exceeds the maximum depth of %v", path, maxFillDepth)
+	}
+
+	switch value.Kind() {
+	case reflect.Ptr:
+		elemType := value.Type().Elem()
+		if filling[elemType] {
+			return
+		}
+		filling[elemType] = true
+		defer delete(filling, elemType)
+		value.Set(reflect.New(elemType))
+		fillStrings(t, value.Elem(), path, depth+1, filling)
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		value.Set(reflect.MakeSlice(value.Type(), 1, 1))
+		fillStrings(t, value.Index(0), path+"[]", depth+1, filling)
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return
+		}
+		key := reflect.New(value.Type().Key()).Elem()
+		key.SetString("key")
+		elem := reflect.New(value.Type().Elem()).Elem()
+		fillStrings(t, elem, path+".key", depth+1, filling)
+		value.Set(reflect.MakeMap(value.Type()))
+		value.SetMapIndex(key, elem)
+	case reflect.Interface:
+		if stringType.Implements(value.Type()) {
+			value.Set(reflect.ValueOf(path))
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if name, ok := jsonFieldName(value.Type().Field(i)); ok {
+				fillStrings(t, value.Field(i), path+"."+name, depth+1, filling)
+			}
+		}
+	case reflect.String:
+		value.SetString(path)
+	}
+}
+
+// collectStrings stores the value of every string set by fillStrings by its json path
+func collectStrings(value reflect.Value, path string, depth int, values map[string]string) {
+
+	if depth > maxFillDepth {
+		return
+	}
+
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !value.IsNil() {
+			collectStrings(value.Elem(), path, depth+1, values)
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			collectStrings(value.Index(i), path+"[]", depth+1, values)
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			collectStrings(iter.Value(), path+"."+iter.Key().String(), depth+1, values)
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if name, ok := jsonFieldName(value.Type().Field(i)); ok {
+				collectStrings(value.Field(i), path+"."+name, depth+1, values)
+			}
+		}
+	case reflect.String:
+		values[path] = value.String()
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+)
+
+type listPage struct {
+	Items      interface{}          `json:"items"`
+	Pagination contracts.Pagination `json:"pagination"`
+}
+
+// paginate splits a slice of items into pages of pageSize items, the way the api pages its list responses
+func paginate(items interface{}, pageSize int) (pages []listPage) {
+
+	value := reflect.ValueOf(items)
+	totalItems := value.Len()
+	totalPages := (totalItems + pageSize - 1) / pageSize
+
+	for pageNumber := 1; pageNumber == 1 || pageNumber <= totalPages; pageNumber++ {
+		start := (pageNumber - 1) * pageSize
+		end := start + pageSize
+		if end > totalItems {
+			end = totalItems
+		}
+		if start > end {
+			start = end
+		}
+
+		pages = append(pages, listPage{
+			Items: value.Slice(start, end).Interface(),
+			Pagination: contracts.Pagination{
+				Page:       pageNumber,
+				Size:       pageSize,
+				TotalPages: totalPages,
+				TotalItems: totalItems,
+			},
+		})
+	}
+
+	return pages
+}
+
+// pageFileName returns the name of the file storing a single page next to the index.json of the full list
+func pageFileName(pageNumber, pageSize int) string {
+	return fmt.Sprintf("page-%v-size-%v.json", pageNumber, pageSize)
+}
+
+// parsePageFileName returns the page number and size of the page stored in the file with name, or false if it doesn't hold a page
+func parsePageFileName(name string) (pageNumber, pageSize int, ok bool) {
+	_, err := fmt.Sscanf(name, "page-%d-size-%d.json", &pageNumber, &pageSize)
+	return pageNumber, pageSize, err == nil && name == pageFileName(pageNumber, pageSize)
+}
+
+// pageQuery returns the query parameters the web app uses to request a single page
+func pageQuery(pageNumber, pageSize int) url.Values {
+	return url.Values{
+		"page[number]": []string{strconv.Itoa(pageNumber)},
+		"page[size]":   []string{strconv.Itoa(pageSize)},
+	}
+}
+
+// isPageQuery returns true if query requests a single page with valid page[number] and page[size] parameters
+func isPageQuery(query url.Values) bool {
+	_, pageNumberErr := strconv.Atoi(query.Get("page[number]"))
+	_, pageSizeErr := strconv.Atoi(query.Get("page[size]"))
+
+	return pageNumberErr == nil && pageSizeErr == nil
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	foundation "github.com/estafette/estafette-foundation"
+	"github.com/rs/zerolog/log"
+)
+
+// PublishOptions configures where and how an export gets published
+type PublishOptions struct {
+	// Repository is cloned into CheckoutDirectory unless that already holds a clone
+	Repository        string
+	Branch            string
+	CheckoutDirectory string
+	// TargetDirectory is the directory in the repository holding the export, of which Subdirectory, the manifest and the endpoints
+	// listed in the manifest get replaced, leaving any other files like hand-written mocks as they are
+	TargetDirectory string
+	Subdirectory    string
+	MessageTemplate string
+	UserName        string
+	UserEmail       string
+	Push            bool
+}
+
+// publishMessageData is passed to the commit message template
+type publishMessageData struct {
+	Manifest ExportManifest
+	Summary  string
+	Report   DiffReport
+}
+
+// publishExport replaces the subdirectory, the manifest and the endpoints listed in the manifests in the target repository with the ones
+// from the export and commits them, unless nothing changed semantically
+func publishExport(ctx context.Context, exportDirectory string, options PublishOptions) (published bool, err error) {
+
+	err = openRepository(ctx, options)
+	if err != nil {
+		return
+	}
+
+	targetDirectory := filepath.Join(options.CheckoutDirectory, filepath.FromSlash(options.TargetDirectory))
+
+	report, err := diffExports(targetDirectory, exportDirectory)
+	if err != nil {
+		return
+	}
+	if report.IsEmpty() {
+		log.Info().Msgf("Export in %v doesn't differ from %v, skipping publish", exportDirectory, targetDirectory)
+		return false, nil
+	}
+
+	// the endpoints of both the published and the new export, so endpoints the new one no longer holds get removed
+	endpoints, err := exportedEndpoints(targetDirectory, exportDirectory)
+	if err != nil {
+		return
+	}
+
+	addArgs := []string{"add", "--all"}
+	for _, path := range []string{options.Subdirectory, manifestFileName} {
+		replaced, err := replaceExportPath(exportDirectory, targetDirectory, path)
+		if err != nil {
+			return false, err
+		}
+		// git refuses to add paths that exist neither in the repository nor on disk
+		if replaced {
+			addArgs = append(addArgs, filepath.ToSlash(filepath.Join(options.TargetDirectory, path)))
+		}
+	}
+	for _, endpoint := range endpoints {
+		path := strings.TrimPrefix(endpoint, "/")
+		replaced, err := replaceEndpointFiles(exportDirectory, targetDirectory, path)
+		if err != nil {
+			return false, err
+		}
+		if replaced {
+			addArgs = append(addArgs, filepath.ToSlash(filepath.Join(options.TargetDirectory, path)))
+		}
+	}
+
+	message, err := renderPublishMessage(options.MessageTemplate, exportDirectory, report)
+	if err != nil {
+		return
+	}
+
+	err = runGit(ctx, options.CheckoutDirectory, addArgs...)
+	if err != nil {
+		return
+	}
+
+	commitArgs := []string{}
+	if options.UserName != "" {
+		commitArgs = append(commitArgs, "-c", "user.name="+options.UserName)
+	}
+	if options.UserEmail != "" {
+		commitArgs = append(commitArgs, "-c", "user.email="+options.UserEmail)
+	}
+	commitArgs = append(commitArgs, "commit", "--allow-empty", "-m", message)
+	err = runGit(ctx, options.CheckoutDirectory, commitArgs...)
+	if err != nil {
+		return
+	}
+
+	if options.Push {
+		err = runGit(ctx, options.CheckoutDirectory, "push", "origin", options.Branch)
+		if err != nil {
+			return
+		}
+	}
+
+	return true, nil
+}
+
+// openRepository clones the repository unless the checkout directory already holds a clone, and checks out the branch
+func openRepository(ctx context.Context, options PublishOptions) error {
+
+	if _, err := os.Stat(filepath.Join(options.CheckoutDirectory, ".git")); err == nil {
+		return runGit(ctx, options.CheckoutDirectory, "checkout", options.Branch)
+	}
+
+	if options.Repository == "" {
+		return fmt.Errorf("%v is not a git repository and no repository to clone is set", options.CheckoutDirectory)
+	}
+
+	return foundation.RunCommandWithArgsExtended(ctx, "git", []string{"clone", "--branch", options.Branch, options.Repository, options.CheckoutDirectory})
+}
+
+func runGit(ctx context.Context, directory string, args ...string) error {
+	return foundation.RunCommandWithArgsExtended(ctx, "git", append([]string{"-C", directory}, args...))
+}
+
+// renderPublishMessage renders the commit message template with the manifest of the export, a summary of it and the diff report
+func renderPublishMessage(messageTemplate, exportDirectory string, report DiffReport) (string, error) {
+
+	manifest, err := readExportManifest(exportDirectory)
+	if err != nil {
+		return "", err
+	}
+
+	data := publishMessageData{
+		Manifest: manifest,
+		Report:   report,
+	}
+
+	counts := []string{}
+	for _, resourceType := range sortedKeys(data.Manifest.Counts) {
+		counts = append(counts, fmt.Sprintf("%v %v", data.Manifest.Counts[resourceType], resourceType))
+	}
+	data.Summary = strings.Join(counts, ", ")
+
+	tmpl, err := template.New("message").Parse(messageTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// replaceExportPath replaces the file or directory at path in targetDirectory with the one in exportDirectory, removing it if the export doesn't hold it;
+// it returns false if neither of them holds it
+func replaceExportPath(exportDirectory, targetDirectory, path string) (replaced bool, err error) {
+
+	targetPath := filepath.Join(targetDirectory, filepath.FromSlash(path))
+	if _, statErr := os.Stat(targetPath); statErr == nil {
+		replaced = true
+	}
+	err = os.RemoveAll(targetPath)
+	if err != nil {
+		return
+	}
+
+	sourcePath := filepath.Join(exportDirectory, filepath.FromSlash(path))
+	if _, statErr := os.Stat(sourcePath); os.IsNotExist(statErr) {
+		return
+	}
+
+	err = os.MkdirAll(filepath.Dir(targetPath), os.ModePerm)
+	if err != nil {
+		return
+	}
+
+	return true, copyDirectory(sourcePath, targetPath)
+}
+
+// replaceEndpointFiles replaces the files directly in the endpoint directory at path in targetDirectory, like its response, pages and
+// handler, with the ones in exportDirectory, leaving its subdirectories, which hold other endpoints, as they are; it returns false if
+// neither of them holds any files there
+func replaceEndpointFiles(exportDirectory, targetDirectory, path string) (replaced bool, err error) {
+
+	targetPath := filepath.Join(targetDirectory, filepath.FromSlash(path))
+	targetFiles, err := ioutil.ReadDir(targetPath)
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+	for _, file := range targetFiles {
+		if file.IsDir() {
+			continue
+		}
+		err = os.Remove(filepath.Join(targetPath, file.Name()))
+		if err != nil {
+			return
+		}
+		replaced = true
+	}
+
+	sourcePath := filepath.Join(exportDirectory, filepath.FromSlash(path))
+	sourceFiles, err := ioutil.ReadDir(sourcePath)
+	if os.IsNotExist(err) {
+		return replaced, nil
+	}
+	if err != nil {
+		return
+	}
+
+	err = os.MkdirAll(targetPath, os.ModePerm)
+	if err != nil {
+		return
+	}
+	for _, file := range sourceFiles {
+		if file.IsDir() {
+			continue
+		}
+		bytes, err := ioutil.ReadFile(filepath.Join(sourcePath, file.Name()))
+		if err != nil {
+			return false, err
+		}
+		err = ioutil.WriteFile(filepath.Join(targetPath, file.Name()), bytes, file.Mode())
+		if err != nil {
+			return false, err
+		}
+		replaced = true
+	}
+
+	return replaced, nil
+}
+
+// copyDirectory copies all files in sourceDirectory to targetDirectory
+func copyDirectory(sourceDirectory, targetDirectory string) error {
+	return filepath.Walk(sourceDirectory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		targetPath := filepath.Join(targetDirectory, strings.TrimPrefix(path, sourceDirectory))
+		if info.IsDir() {
+			return os.MkdirAll(targetPath, os.ModePerm)
+		}
+
+		bytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return ioutil.WriteFile(targetPath, bytes, info.Mode())
+	})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishExport(t *testing.T) {
+	t.Run("CommitsChangedExportAndSkipsUnchangedOne", func(t *testing.T) {
+
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("skipping test without git.")
+		}
+
+		ctx := context.Background()
+		parent, err := ioutil.TempDir("", "publish")
+		assert.Nil(t, err)
+		defer os.RemoveAll(parent)
+
+		// create a bare repository with an initial commit on main
+		bareRepository := filepath.Join(parent, "web.git")
+		seedDirectory := filepath.Join(parent, "seed")
+		runTestGit(t, parent, "init", "--bare", bareRepository)
+		runTestGit(t, parent, "init", seedDirectory)
+		runTestGit(t, seedDirectory, "checkout", "-b", "main")
+		// a hand-written mock next to the export, which publishing has to leave as it is
+		err = os.MkdirAll(filepath.Join(seedDirectory, "mocks", "api", "users", "me"), os.ModePerm)
+		assert.Nil(t, err)
+		err = ioutil.WriteFile(filepath.Join(seedDirectory, "mocks", "api", "users", "me", "index.json"), []byte(`{"email":"me@estafette.io"}`), 0644)
+		assert.Nil(t, err)
+		runTestGit(t, seedDirectory, "add", "--all")
+		runTestGit(t, seedDirectory, "-c", "user.name=Just Me", "-c", "user.email=me@estafette.io", "commit", "-m", "initial")
+		runTestGit(t, seedDirectory, "push", bareRepository, "main")
+
+		exportDirectory := createMockDirectory(t, map[string]string{
+			"api/pipelines/index.json":        `{"items":[{"repoSource":"github.com","repoOwner":"estafette","repoName":"estafette-ci-api"}]}`,
+			"api/stats/mostbuilds/index.json": `{"items":[]}`,
+			"manifest.json":                   `{"tool":{"version":"1.0.0"},"apiHost":"api.estafette.io","counts":{"pipelines":1},"endpoints":["/api/stats/mostbuilds"]}`,
+		})
+		defer os.RemoveAll(exportDirectory)
+
+		options := PublishOptions{
+			Repository:        bareRepository,
+			Branch:            "main",
+			CheckoutDirectory: filepath.Join(parent, "web"),
+			TargetDirectory:   "mocks",
+			Subdirectory:      "api/pipelines",
+			MessageTemplate:   "estafette-ci-demo {{.Manifest.Tool.Version}}: {{.Summary}} from {{.Manifest.APIHost}}",
+			UserName:          "Just Me",
+			UserEmail:         "me@estafette.io",
+			Push:              true,
+		}
+
+		// act
+		published, err := publishExport(ctx, exportDirectory, options)
+
+		assert.Nil(t, err)
+		assert.True(t, published)
+		assert.Equal(t, "estafette-ci-demo 1.0.0: 1 pipelines from api.estafette.io", runTestGit(t, parent, "--git-dir", bareRepository, "log", "-1", "--format=%s", "main"))
+		assert.Equal(t, "mocks/api/pipelines/index.json\nmocks/api/stats/mostbuilds/index.json\nmocks/api/users/me/index.json\nmocks/manifest.json", runTestGit(t, parent, "--git-dir", bareRepository, "ls-tree", "-r", "--name-only", "main"))
+		assert.FileExists(t, filepath.Join(parent, "web", "mocks", "api", "users", "me", "index.json"))
+
+		// act
+		published, err = publishExport(ctx, exportDirectory, options)
+
+		assert.Nil(t, err)
+		assert.False(t, published)
+		assert.Equal(t, "2", runTestGit(t, parent, "--git-dir", bareRepository, "rev-list", "--count", "main"))
+	})
+}
+
+func runTestGit(t *testing.T, directory string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = directory
+	output, err := cmd.CombinedOutput()
+	assert.Nil(t, err, string(output))
+
+	return strings.TrimSpace(string(output))
+}
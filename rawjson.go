@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// RawResponses keeps the raw body of api responses by path, so they can be stored with their original structure
+type RawResponses struct {
+	mutex     sync.Mutex
+	responses map[string][]byte
+	paged     map[string]bool
+}
+
+// NewRawResponses returns an empty RawResponses
+func NewRawResponses() *RawResponses {
+	return &RawResponses{
+		responses: map[string][]byte{},
+		paged:     map[string]bool{},
+	}
+}
+
+// Add keeps the raw response from path; lists fetched in multiple pages don't match a single response, so they're dropped
+func (r *RawResponses) Add(path string, data []byte) {
+
+	path = strings.SplitN(path, "?", 2)[0]
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.responses[path]; ok || r.paged[path] {
+		delete(r.responses, path)
+		r.paged[path] = true
+		return
+	}
+
+	r.responses[path] = data
+}
+
+// Get returns the raw response from path, if there's a single one
+func (r *RawResponses) Get(path string) ([]byte, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	data, ok := r.responses[path]
+	return data, ok
+}
+
+// obfuscateRawJSON applies the obfuscation of object, decoded from data, to data itself, keeping field order, nulls and number formats;
+// strings in fields unknown to the type of object get the obfuscation for unknown values; changed numbers, like rewritten pagination, are applied as well;
+// it returns false if object no longer matches data
+func obfuscateRawJSON(data []byte, object interface{}, obfuscator *Obfuscator) ([]byte, bool, error) {
+
+	if object == nil {
+		return nil, false, nil
+	}
+
+	// decode data into a fresh object of the same type, so the typed json before and after obfuscation only differs in obfuscated values
+	original := reflect.New(reflect.TypeOf(object))
+	err := json.Unmarshal(data, original.Interface())
+	if err != nil {
+		return nil, false, err
+	}
+
+	before, err := typedJSONDocument(original.Elem().Interface())
+	if err != nil {
+		return nil, false, err
+	}
+	after, err := typedJSONDocument(object)
+	if err != nil {
+		return nil, false, err
+	}
+
+	edits := map[string][]byte{}
+	if !collectValueEdits(before, after, "", edits) {
+		return nil, false, nil
+	}
+
+	raw, err := decodeRawJSON(data)
+	if err != nil {
+		return nil, false, err
+	}
+	collectUnknownStringEdits(raw, reflect.TypeOf(object), "", false, obfuscator, edits)
+
+	edited, err := editJSONValues(data, edits)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return edited, true, nil
+}
+
+// typedJSONDocument marshals object and decodes it again without a target type
+func typedJSONDocument(object interface{}) (interface{}, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRawJSON(data)
+}
+
+// collectValueEdits adds the path and new json literal of every string or number that differs between before and after to edits,
+// or returns false if they differ in anything else
+func collectValueEdits(before, after interface{}, path string, edits map[string][]byte) bool {
+
+	switch b := before.(type) {
+	case map[string]interface{}:
+		a, ok := after.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, v := range b {
+			afterValue, ok := a[key]
+			if !ok || !collectValueEdits(v, afterValue, jsonChildPath(path, key), edits) {
+				return false
+			}
+		}
+		return true
+
+	case []interface{}:
+		a, ok := after.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range b {
+			if !collectValueEdits(b[i], a[i], jsonIndexPath(path, i), edits) {
+				return false
+			}
+		}
+		return true
+
+	case string:
+		a, ok := after.(string)
+		if !ok {
+			return false
+		}
+		if a != b {
+			edits[path] = marshalJSONString(a)
+		}
+		return true
+
+	case json.Number:
+		a, ok := after.(json.Number)
+		if !ok {
+			return false
+		}
+		if a != b {
+			edits[path] = []byte(a.String())
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(before, after)
+}
+
+// collectUnknownStringEdits adds an edit for every string in a field of raw unknown to t that changes when obfuscated as unknown value
+func collectUnknownStringEdits(raw interface{}, t reflect.Type, path string, unknown bool, obfuscator *Obfuscator, edits map[string][]byte) {
+
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch value := raw.(type) {
+	case map[string]interface{}:
+		for key, v := range value {
+			var fieldType reflect.Type
+			fieldUnknown := unknown
+			if !unknown && t != nil {
+				switch t.Kind() {
+				case reflect.Struct:
+					var ok bool
+					fieldType, ok = jsonFieldType(t, key)
+					fieldUnknown = !ok
+				case reflect.Map:
+					fieldType = t.Elem()
+				}
+			}
+			collectUnknownStringEdits(v, fieldType, jsonChildPath(path, key), fieldUnknown, obfuscator, edits)
+		}
+
+	case []interface{}:
+		var itemType reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			itemType = t.Elem()
+		}
+		for i, v := range value {
+			collectUnknownStringEdits(v, itemType, jsonIndexPath(path, i), unknown, obfuscator, edits)
+		}
+
+	case string:
+		if !unknown {
+			return
+		}
+		if obfuscated := obfuscator.unknownString(value); obfuscated != value {
+			edits[path] = marshalJSONString(obfuscated)
+		}
+	}
+}
+
+// jsonChildPath returns the path of field key within the object at path; keys are matched case-insensitively like encoding/json does
+func jsonChildPath(path, key string) string {
+	if path == "" {
+		return strings.ToLower(key)
+	}
+	return path + "." + strings.ToLower(key)
+}
+
+// jsonIndexPath returns the path of item i within the array at path
+func jsonIndexPath(path string, i int) string {
+	return fmt.Sprintf("%v[%v]", path, i)
+}
+
+// editJSONStrings replaces the string values at the paths in edits, leaving every other byte of data as it is
+func editJSONStrings(data []byte, edits map[string]string) ([]byte, error) {
+
+	literals := make(map[string][]byte, len(edits))
+	for path, value := range edits {
+		literals[path] = marshalJSONString(value)
+	}
+
+	return editJSONValues(data, literals)
+}
+
+// editJSONValues replaces the strings, numbers, booleans or nulls at the paths in edits with the json literals in edits,
+// leaving every other byte of data as it is
+func editJSONValues(data []byte, edits map[string][]byte) ([]byte, error) {
+
+	if len(edits) == 0 {
+		return data, nil
+	}
+
+	scanner := &jsonEditor{data: data, edits: edits}
+	err := scanner.value("")
+	if err != nil {
+		return nil, err
+	}
+	scanner.output.Write(data[scanner.copied:])
+
+	return scanner.output.Bytes(), nil
+}
+
+// jsonEditor walks a json document and copies it to output, replacing edited values on the way
+type jsonEditor struct {
+	data   []byte
+	edits  map[string][]byte
+	pos    int
+	copied int
+	output bytes.Buffer
+}
+
+func (s *jsonEditor) value(path string) error {
+
+	s.skipWhitespace()
+	if s.pos >= len(s.data) {
+		return fmt.Errorf("unexpected end of json at %v", s.pos)
+	}
+
+	switch s.data[s.pos] {
+	case '{':
+		s.pos++
+		s.skipWhitespace()
+		if s.consume('}') {
+			return nil
+		}
+		for {
+			s.skipWhitespace()
+			key, err := s.string()
+			if err != nil {
+				return err
+			}
+			s.skipWhitespace()
+			if !s.consume(':') {
+				return fmt.Errorf("expected colon in json at %v", s.pos)
+			}
+			err = s.value(jsonChildPath(path, key))
+			if err != nil {
+				return err
+			}
+			s.skipWhitespace()
+			if s.consume('}') {
+				return nil
+			}
+			if !s.consume(',') {
+				return fmt.Errorf("expected comma in json at %v", s.pos)
+			}
+		}
+
+	case '[':
+		s.pos++
+		s.skipWhitespace()
+		if s.consume(']') {
+			return nil
+		}
+		for i := 0; ; i++ {
+			err := s.value(jsonIndexPath(path, i))
+			if err != nil {
+				return err
+			}
+			s.skipWhitespace()
+			if s.consume(']') {
+				return nil
+			}
+			if !s.consume(',') {
+				return fmt.Errorf("expected comma in json at %v", s.pos)
+			}
+		}
+
+	case '"':
+		start := s.pos
+		_, err := s.string()
+		if err != nil {
+			return err
+		}
+		s.replace(path, start)
+		return nil
+
+	default:
+		// numbers, booleans and null
+		start := s.pos
+		for s.pos < len(s.data) && !strings.ContainsRune(",}] \t\r\n", rune(s.data[s.pos])) {
+			s.pos++
+		}
+		s.replace(path, start)
+		return nil
+	}
+}
+
+// replace writes the edit for path in place of the value read from start, if there is one
+func (s *jsonEditor) replace(path string, start int) {
+	if replacement, ok := s.edits[path]; ok {
+		s.output.Write(s.data[s.copied:start])
+		s.output.Write(replacement)
+		s.copied = s.pos
+	}
+}
+
+// string reads a json string literal and returns its decoded value
+func (s *jsonEditor) string() (string, error) {
+
+	if s.pos >= len(s.data) || s.data[s.pos] != '"' {
+		return "", fmt.Errorf("expected string in json at %v", s.pos)
+	}
+
+	start := s.pos
+	for s.pos++; s.pos < len(s.data); s.pos++ {
+		switch s.data[s.pos] {
+		case '\\':
+			s.pos++
+		case '"':
+			s.pos++
+			var value string
+			err := json.Unmarshal(s.data[start:s.pos], &value)
+			return value, err
+		}
+	}
+
+	return "", fmt.Errorf("unterminated string in json at %v", start)
+}
+
+func (s *jsonEditor) consume(c byte) bool {
+	if s.pos < len(s.data) && s.data[s.pos] == c {
+		s.pos++
+		return true
+	}
+	return false
+}
+
+func (s *jsonEditor) skipWhitespace() {
+	for s.pos < len(s.data) && strings.ContainsRune(" \t\r\n", rune(s.data[s.pos])) {
+		s.pos++
+	}
+}
+
+// marshalJSONString returns value as json string literal without escaping html characters
+func marshalJSONString(value string) []byte {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value)
+	return bytes.TrimRight(buffer.Bytes(), "\n")
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+const rawBuildResponse = `{"repoName":"fake-pipeline-1","id":"1001","duration":1500000000,"score":1.50,"buildVersion":null,
+  "commits":[{"message":"fix <b>","author":{"email":"jane@example.com","name":"Jane","username":"jane"}}],
+  "reviewer":{"email":"john@example.com"}}`
+
+func TestEditJSONStrings(t *testing.T) {
+	t.Run("ReplacesOnlyEditedStrings", func(t *testing.T) {
+
+		data := []byte(`{"b": 1.50, "a": null, "items": [ {"Email":"jane@example.com", "tags":["x\"y"]} ]}`)
+
+		// act
+		edited, err := editJSONStrings(data, map[string]string{"items[0].email": "me@estafette.io <me>"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, `{"b": 1.50, "a": null, "items": [ {"Email":"me@estafette.io <me>", "tags":["x\"y"]} ]}`, string(edited))
+	})
+
+	t.Run("ReturnsErrorForInvalidJSON", func(t *testing.T) {
+
+		// act
+		_, err := editJSONStrings([]byte(`{"a": "b"`), map[string]string{"a": "c"})
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestObfuscateRawJSON(t *testing.T) {
+	t.Run("KeepsStructureOfRawResponse", func(t *testing.T) {
+
+		obfuscator := newTestObfuscator(t)
+		var build contracts.Build
+		err := json.Unmarshal([]byte(rawBuildResponse), &build)
+		assert.Nil(t, err)
+		obfuscator.Build(&build)
+
+		// act
+		obfuscated, ok, err := obfuscateRawJSON([]byte(rawBuildResponse), &build, obfuscator)
+
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, `{"repoName":"fake-pipeline-1","id":"1001","duration":1500000000,"score":1.50,"buildVersion":null,
+  "commits":[{"message":"fix <b>","author":{"email":"me@estafette.io","name":"Just Me","username":"JustMe"}}],
+  "reviewer":{"email":"me@estafette.io"}}`, string(obfuscated))
+	})
+
+	t.Run("AppliesChangedNumbersToRawResponse", func(t *testing.T) {
+
+		data := []byte(`{"items": [{"id":"1"}], "pagination": {"page": 1, "size": 10, "totalPages": 3, "totalItems": 27}}`)
+		var builds PipelineBuildsListResponse
+		err := json.Unmarshal(data, &builds)
+		assert.Nil(t, err)
+		builds.Pagination.TotalPages = 1
+		builds.Pagination.TotalItems = len(builds.Items)
+
+		// act
+		obfuscated, ok, err := obfuscateRawJSON(data, &builds, newTestObfuscator(t))
+
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, `{"items": [{"id":"1"}], "pagination": {"page": 1, "size": 10, "totalPages": 1, "totalItems": 1}}`, string(obfuscated))
+	})
+
+	t.Run("ReturnsFalseIfObjectDiffersFromRawResponse", func(t *testing.T) {
+
+		var build contracts.Build
+		err := json.Unmarshal([]byte(rawBuildResponse), &build)
+		assert.Nil(t, err)
+		build.Commits = nil
+
+		// act
+		_, ok, err := obfuscateRawJSON([]byte(rawBuildResponse), &build, newTestObfuscator(t))
+
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestRawResponses(t *testing.T) {
+	t.Run("DropsListsFetchedInMultiplePages", func(t *testing.T) {
+
+		rawResponses := NewRawResponses()
+
+		// act
+		rawResponses.Add("/api/labels?page[number]=1", []byte(`{"items":[]}`))
+		rawResponses.Add("/api/labels?page[number]=2", []byte(`{"items":[]}`))
+		rawResponses.Add("/api/labels?page[number]=3", []byte(`{"items":[]}`))
+		rawResponses.Add("/api/organizations?page[number]=1", []byte(`{"items":[]}`))
+
+		_, ok := rawResponses.Get("/api/labels")
+		assert.False(t, ok)
+		data, ok := rawResponses.Get("/api/organizations")
+		assert.True(t, ok)
+		assert.Equal(t, `{"items":[]}`, string(data))
+	})
+}
+
+func TestExtractorRunWithRawResponses(t *testing.T) {
+	t.Run("StoresObfuscatedRawResponses", func(t *testing.T) {
+
+		dataset := newFakeDataset(1, 2)
+		path := "/api/pipelines/github.com/estafette/fake-pipeline-1/builds/1001"
+		build, err := dataset.lookup(path)
+		assert.Nil(t, err)
+		data, err := json.Marshal(build)
+		assert.Nil(t, err)
+		rawResponses := NewRawResponses()
+		rawResponses.Add(path, append(data[:len(data)-1], []byte(`,"reviewer":"`+fakeAuthorEmail+`"}`)...))
+		sink := NewMemorySink()
+		extractor := NewExtractor(NewFakeApiClient(dataset), sink, newTestObfuscator(t), ExtractorOptions{Pipelines: dataset.pipelinePaths(), RawResponses: rawResponses})
+
+		// act
+		err = extractor.Run(context.Background())
+
+		assert.Nil(t, err)
+		stored := string(sink.Files()["api/pipelines/github.com/estafette/fake-pipeline-1/builds/1001/index.json"])
+		assert.Contains(t, stored, `"reviewer":"`+obfuscatedEmail+`"`)
+		assert.NotContains(t, stored, fakeAuthorEmail)
+		assert.NotContains(t, stored, "\n")
+	})
+
+	t.Run("StoresRawListWithMorePagesThanExtracted", func(t *testing.T) {
+
+		dataset := newFakeDataset(1, 2)
+		path := "/api/pipelines/github.com/estafette/fake-pipeline-1/builds"
+		builds := dataset.responses[path].(PipelineBuildsListResponse)
+		builds.Pagination.TotalPages = 3
+		builds.Pagination.TotalItems = 27
+		dataset.responses[path] = builds
+		data, err := json.Marshal(builds)
+		assert.Nil(t, err)
+		rawResponses := NewRawResponses()
+		rawResponses.Add(path, data)
+		sink := NewMemorySink()
+		extractor := NewExtractor(NewFakeApiClient(dataset), sink, newTestObfuscator(t), ExtractorOptions{Pipelines: dataset.pipelinePaths(), RawResponses: rawResponses})
+
+		// act
+		err = extractor.Run(context.Background())
+
+		assert.Nil(t, err)
+		stored := string(sink.Files()["api/pipelines/github.com/estafette/fake-pipeline-1/builds/index.json"])
+		assert.Contains(t, stored, `"totalPages":1,"totalItems":2`)
+		assert.NotContains(t, stored, "\n")
+	})
+}
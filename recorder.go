@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+)
+
+// recordResponses runs a reverse proxy in front of the api and stores every successful GET response passing through it as obfuscated mock response
+func recordResponses(ctx context.Context, apiBaseURL, clientID, clientSecret, listenAddress string, sink FixtureSink, obfuscator *Obfuscator) error {
+
+	apiClient := NewApiClient(apiBaseURL)
+
+	token, err := apiClient.GetToken(ctx, clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+
+	recorder, err := NewRecorder(apiBaseURL, token, sink, obfuscator)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Recording responses from %v on %v", apiBaseURL, listenAddress)
+
+	server := &http.Server{
+		Addr:    listenAddress,
+		Handler: recorder,
+	}
+
+	// keep recording until interrupted, then finalize the sink
+	done := make(chan error, 1)
+	go func() {
+		done <- server.ListenAndServe()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err = <-done:
+		return err
+	case <-signals:
+	}
+
+	err = server.Shutdown(ctx)
+	if err != nil {
+		return err
+	}
+
+	return sink.Finalize()
+}
+
+// NewRecorder returns an http.Handler proxying requests to the api and storing the responses obfuscated in sink
+func NewRecorder(apiBaseURL, token string, sink FixtureSink, obfuscator *Obfuscator) (http.Handler, error) {
+
+	target, err := url.Parse(apiBaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		r.Host = target.Host
+		r.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+		// request uncompressed responses, so they can be obfuscated before storing
+		r.Header.Del("Accept-Encoding")
+	}
+	proxy.ModifyResponse = func(response *http.Response) error {
+		return recordResponse(response, sink, obfuscator)
+	}
+	// flush streamed responses like logs.stream straight away
+	proxy.FlushInterval = -1
+
+	// every forwarded request carries the token of the exporter, so only let through requests that can't change anything
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}), nil
+}
+
+func recordResponse(response *http.Response, sink FixtureSink, obfuscator *Obfuscator) error {
+
+	request := response.Request
+	if request.Method != http.MethodGet || response.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	// filtered lists are derived from the full list by the mock api
+	query := request.URL.Query()
+	if hasMockFilters(query) {
+		return nil
+	}
+
+	response.Body = &recordingBody{
+		ReadCloser:  response.Body,
+		sink:        sink,
+		obfuscator:  obfuscator,
+		path:        request.URL.Path,
+		query:       query,
+		eventStream: strings.HasPrefix(response.Header.Get("Content-Type"), "text/event-stream"),
+	}
+
+	return nil
+}
+
+// recordingBody passes through the response body while keeping a copy, which gets stored once the body is closed after being read completely
+type recordingBody struct {
+	io.ReadCloser
+	sink        FixtureSink
+	obfuscator  *Obfuscator
+	path        string
+	query       url.Values
+	eventStream bool
+	buffer      bytes.Buffer
+	complete    bool
+}
+
+func (b *recordingBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.buffer.Write(p[:n])
+	if err == io.EOF {
+		b.complete = true
+	}
+	return
+}
+
+func (b *recordingBody) Close() error {
+	err := b.ReadCloser.Close()
+
+	// a client disconnecting halfway leaves a truncated response, which shouldn't replace a complete one
+	if !b.complete {
+		log.Warn().Msgf("Skipped recording incomplete response for %v", b.path)
+		return err
+	}
+
+	saveErr := b.save()
+	if saveErr != nil {
+		log.Error().Err(saveErr).Msgf("Failed recording response for %v", b.path)
+	}
+
+	return err
+}
+
+func (b *recordingBody) save() error {
+
+	if b.eventStream {
+		return saveSSEBytes(b.sink, b.path, b.obfuscator.Log(b.buffer.Bytes()))
+	}
+
+	data, err := b.obfuscator.Response(b.path, b.buffer.Bytes())
+	if err != nil {
+		return err
+	}
+
+	// store a requested page next to the full list, with the first page doubling as full list
+	pageNumber, pageNumberErr := strconv.Atoi(b.query.Get("page[number]"))
+	pageSize, pageSizeErr := strconv.Atoi(b.query.Get("page[size]"))
+	if pageNumberErr == nil && pageSizeErr == nil {
+		err = b.sink.WriteJSON(b.path, pageQuery(pageNumber, pageSize), data)
+		if err != nil || pageNumber != 1 {
+			return err
+		}
+	}
+
+	return saveBytes(b.sink, b.path, data)
+}
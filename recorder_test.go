@@ -0,0 +1,155 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Run("StoresObfuscatedResponseInFixtureLayout", func(t *testing.T) {
+
+		sink := NewMemorySink()
+
+		var authorizationHeader string
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorizationHeader = r.Header.Get("Authorization")
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"5","repoName":"estafette-ci-api","commits":[{"author":{"email":"jane@example.com","name":"Jane"}}]}`))
+		}))
+		defer api.Close()
+
+		recorder, err := NewRecorder(api.URL, "abc", sink, newTestObfuscator(t))
+		assert.Nil(t, err)
+		request := httptest.NewRequest("GET", "/api/pipelines/github.com/estafette/estafette-ci-api/builds/5", nil)
+		response := httptest.NewRecorder()
+
+		// act
+		recorder.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, response.Body.String(), "jane@example.com")
+		assert.Equal(t, "Bearer abc", authorizationHeader)
+		bytes, ok := sink.Files()["api/pipelines/github.com/estafette/estafette-ci-api/builds/5/index.json"]
+		assert.True(t, ok)
+		assert.NotContains(t, string(bytes), "jane@example.com")
+		assert.Contains(t, string(bytes), "me@estafette.io")
+	})
+
+	t.Run("SkipsFilteredResponses", func(t *testing.T) {
+
+		sink := NewMemorySink()
+
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"items":[]}`))
+		}))
+		defer api.Close()
+
+		recorder, err := NewRecorder(api.URL, "abc", sink, newTestObfuscator(t))
+		assert.Nil(t, err)
+		request := httptest.NewRequest("GET", "/api/pipelines?filter[status]=running", nil)
+		response := httptest.NewRecorder()
+
+		// act
+		recorder.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, 0, len(sink.Files()))
+	})
+
+	t.Run("RejectsRequestsOtherThanGetAndHead", func(t *testing.T) {
+
+		sink := NewMemorySink()
+
+		forwarded := false
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			forwarded = true
+		}))
+		defer api.Close()
+
+		recorder, err := NewRecorder(api.URL, "abc", sink, newTestObfuscator(t))
+		assert.Nil(t, err)
+		request := httptest.NewRequest("DELETE", "/api/pipelines/github.com/estafette/estafette-ci-api/builds/5", nil)
+		response := httptest.NewRecorder()
+
+		// act
+		recorder.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+		assert.False(t, forwarded)
+	})
+}
+
+func TestRecorderObfuscatesResponsesOutsidePipelines(t *testing.T) {
+
+	testCases := []struct {
+		path     string
+		response string
+	}{
+		{"/api/builds", `{"items":[{"id":"5","commits":[{"author":{"email":"jane@example.com","name":"Jane"}}]}],"pagination":{"page":1,"size":1,"totalPages":1,"totalItems":1}}`},
+		{"/api/releases", `{"items":[{"id":"6","events":[{"manual":{"userID":"jane@example.com"}}]}],"pagination":{"page":1,"size":1,"totalPages":1,"totalItems":1}}`},
+		{"/api/catalog/entities", `{"items":[{"key":"team","value":"estafette","metadata":{"owners":[{"email":"jane@example.com"}]}}],"pagination":{"page":1,"size":1,"totalPages":1,"totalItems":1}}`},
+		{"/api/users/me", `{"id":"7","email":"jane@example.com","identities":[{"provider":"google","email":"jane@example.com"}]}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+
+			sink := NewMemorySink()
+
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tc.response))
+			}))
+			defer api.Close()
+
+			recorder, err := NewRecorder(api.URL, "abc", sink, newTestObfuscator(t))
+			assert.Nil(t, err)
+			request := httptest.NewRequest("GET", tc.path, nil)
+			response := httptest.NewRecorder()
+
+			// act
+			recorder.ServeHTTP(response, request)
+
+			assert.Equal(t, http.StatusOK, response.Code)
+			bytes, ok := sink.Files()[strings.TrimPrefix(tc.path, "/")+"/index.json"]
+			assert.True(t, ok)
+			assert.NotContains(t, string(bytes), "jane@example.com")
+			assert.Contains(t, string(bytes), "me@estafette.io")
+		})
+	}
+}
+
+func TestRecordingBody(t *testing.T) {
+	t.Run("StoresBodyReadCompletely", func(t *testing.T) {
+
+		sink := NewMemorySink()
+		body := &recordingBody{ReadCloser: ioutil.NopCloser(strings.NewReader(`{"id":"5"}`)), sink: sink, obfuscator: newTestObfuscator(t), path: "/api/pipelines/github.com/estafette/estafette-ci-api/builds/5"}
+		_, err := ioutil.ReadAll(body)
+		assert.Nil(t, err)
+
+		// act
+		err = body.Close()
+
+		assert.Nil(t, err)
+		assert.Contains(t, sink.Files(), "api/pipelines/github.com/estafette/estafette-ci-api/builds/5/index.json")
+	})
+
+	t.Run("SkipsBodyClosedBeforeEnd", func(t *testing.T) {
+
+		sink := NewMemorySink()
+		body := &recordingBody{ReadCloser: ioutil.NopCloser(strings.NewReader(`{"id":"5"}`)), sink: sink, obfuscator: newTestObfuscator(t), path: "/api/pipelines/github.com/estafette/estafette-ci-api/builds/5"}
+		_, err := body.Read(make([]byte, 4))
+		assert.Nil(t, err)
+
+		// act
+		err = body.Close()
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(sink.Files()))
+	})
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// SchemaDrift collects the fields in api responses that are unknown to the contracts version the tool is built with,
+// and keeps the raw responses holding them so they can be restored when storing the response
+type SchemaDrift struct {
+	mutex     sync.Mutex
+	endpoints map[string]map[string]bool
+	responses map[string]interface{}
+}
+
+// NewSchemaDrift returns an empty SchemaDrift
+func NewSchemaDrift() *SchemaDrift {
+	return &SchemaDrift{
+		endpoints: map[string]map[string]bool{},
+		responses: map[string]interface{}{},
+	}
+}
+
+// Check records the fields in the json response from path that don't exist in the type of target, under endpoint
+func (d *SchemaDrift) Check(endpoint, path string, data []byte, target interface{}) error {
+
+	raw, err := decodeRawJSON(data)
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]bool{}
+	collectUnknownFields(raw, reflect.TypeOf(target), "", fields)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.endpoints[endpoint] == nil {
+		d.endpoints[endpoint] = map[string]bool{}
+	}
+	for f := range fields {
+		d.endpoints[endpoint][f] = true
+	}
+
+	// keep the items of every page of a list under the path of the full list
+	path = strings.SplitN(path, "?", 2)[0]
+	d.responses[path] = appendItems(d.responses[path], raw)
+
+	return nil
+}
+
+// IsEmpty returns true if no unknown fields have been found
+func (d *SchemaDrift) IsEmpty() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return len(d.endpoints) == 0
+}
+
+// Fields returns the sorted unknown fields per endpoint
+func (d *SchemaDrift) Fields() map[string][]string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	fields := map[string][]string{}
+	for endpoint, endpointFields := range d.endpoints {
+		for f := range endpointFields {
+			fields[endpoint] = append(fields[endpoint], f)
+		}
+		sort.Strings(fields[endpoint])
+	}
+
+	return fields
+}
+
+// Log logs the unknown fields per endpoint
+func (d *SchemaDrift) Log() {
+	for endpoint, fields := range d.Fields() {
+		log.Warn().Strs("fields", fields).Msgf("Responses of %v hold fields unknown to the contracts version", endpoint)
+	}
+}
+
+// Restore adds the unknown fields of the raw response from path to the json marshalled from its typed object, passing each of them through obfuscate
+func (d *SchemaDrift) Restore(path string, object interface{}, data []byte, obfuscate func(value interface{}) interface{}) ([]byte, error) {
+
+	d.mutex.Lock()
+	raw, ok := d.responses[path]
+	d.mutex.Unlock()
+	if !ok {
+		return data, nil
+	}
+
+	typed, err := decodeRawJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	typed = restoreUnknownFields(raw, typed, reflect.TypeOf(object), obfuscate)
+
+	return json.MarshalIndent(typed, "", "  ")
+}
+
+// decodeRawJSON decodes json without a target type, keeping numbers as they are
+func decodeRawJSON(data []byte) (raw interface{}, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&raw)
+	return
+}
+
+// collectUnknownFields adds the path of every field in raw that doesn't exist in t to fields
+func collectUnknownFields(raw interface{}, t reflect.Type, path string, fields map[string]bool) {
+
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return
+	}
+
+	switch value := raw.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		for key, v := range value {
+			fieldType, ok := jsonFieldType(t, key)
+			if !ok {
+				fields[strings.TrimPrefix(path+"."+key, ".")] = true
+				continue
+			}
+			collectUnknownFields(v, fieldType, path+"."+key, fields)
+		}
+
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for _, v := range value {
+			collectUnknownFields(v, t.Elem(), path+"[]", fields)
+		}
+	}
+}
+
+// restoreUnknownFields copies the fields in raw that don't exist in t into typed, matching list items by id or position
+func restoreUnknownFields(raw, typed interface{}, t reflect.Type, obfuscate func(value interface{}) interface{}) interface{} {
+
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return typed
+	}
+
+	switch rawValue := raw.(type) {
+	case map[string]interface{}:
+		typedValue, ok := typed.(map[string]interface{})
+		if !ok || t.Kind() != reflect.Struct {
+			return typed
+		}
+		for key, v := range rawValue {
+			fieldType, ok := jsonFieldType(t, key)
+			if !ok {
+				typedValue[key] = obfuscate(v)
+				continue
+			}
+			if typedField, ok := typedValue[key]; ok {
+				typedValue[key] = restoreUnknownFields(v, typedField, fieldType, obfuscate)
+			}
+		}
+		return typedValue
+
+	case []interface{}:
+		typedValue, ok := typed.([]interface{})
+		if !ok || (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) {
+			return typed
+		}
+		rawByID := map[string]interface{}{}
+		for _, v := range rawValue {
+			if id := itemID(v); id != "" {
+				rawByID[id] = v
+			}
+		}
+		for i, v := range typedValue {
+			if rawItem, ok := rawByID[itemID(v)]; ok && itemID(v) != "" {
+				typedValue[i] = restoreUnknownFields(rawItem, v, t.Elem(), obfuscate)
+			} else if len(rawValue) == len(typedValue) {
+				typedValue[i] = restoreUnknownFields(rawValue[i], v, t.Elem(), obfuscate)
+			}
+		}
+		return typedValue
+	}
+
+	return typed
+}
+
+// jsonFieldType returns the type of the field of struct type t that's serialized as name
+func jsonFieldType(t reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if fieldType, ok := jsonFieldType(field.Type, name); ok {
+				return fieldType, true
+			}
+			continue
+		}
+		fieldName, ok := jsonFieldName(field)
+		if ok && strings.EqualFold(fieldName, name) {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+// jsonFieldName returns the name a field is serialized with, or false if it isn't serialized
+func jsonFieldName(field reflect.StructField) (string, bool) {
+
+	if field.PkgPath != "" {
+		return "", false
+	}
+
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = field.Name
+	}
+
+	return name, true
+}
+
+// itemID returns the id of a list item, if it has one
+func itemID(item interface{}) string {
+	if object, ok := item.(map[string]interface{}); ok {
+		if id, ok := object["id"].(string); ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// appendItems combines the items of pages of the same list, or replaces the previous response for other responses
+func appendItems(previous, raw interface{}) interface{} {
+
+	previousList, ok := previous.(map[string]interface{})
+	if !ok {
+		return raw
+	}
+	list, ok := raw.(map[string]interface{})
+	if !ok {
+		return raw
+	}
+	previousItems, ok := previousList["items"].([]interface{})
+	if !ok {
+		return raw
+	}
+	items, ok := list["items"].([]interface{})
+	if !ok {
+		return raw
+	}
+
+	previousList["items"] = append(previousItems, items...)
+
+	return previousList
+}
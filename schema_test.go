@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	contracts "github.com/estafette/estafette-ci-contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+const schemaDriftBuildsResponse = `{
+  "items": [
+    {
+      "id": "1001",
+      "repoName": "fake-pipeline-1",
+      "commits": [{"message": "fix", "author": {"email": "jane@example.com", "avatar": "https://avatars/jane@example.com"}}],
+      "queuedAt": "2021-01-01T00:00:00Z"
+    }
+  ],
+  "pagination": {"page": 1, "size": 20, "totalPages": 1, "totalItems": 1},
+  "facets": {"branches": 1}
+}`
+
+func TestSchemaDriftCheck(t *testing.T) {
+	t.Run("ReportsUnknownFieldsPerEndpoint", func(t *testing.T) {
+
+		schemaDrift := NewSchemaDrift()
+		var response PipelineBuildsListResponse
+
+		// act
+		err := schemaDrift.Check("/api/pipelines/{source}/{owner}/{repo}/builds", "/api/pipelines/github.com/estafette/fake-pipeline-1/builds?page[size]=20", []byte(schemaDriftBuildsResponse), &response)
+
+		assert.Nil(t, err)
+		assert.False(t, schemaDrift.IsEmpty())
+		assert.Equal(t, map[string][]string{
+			"/api/pipelines/{source}/{owner}/{repo}/builds": {"facets", "items[].commits[].author.avatar", "items[].queuedAt"},
+		}, schemaDrift.Fields())
+	})
+
+	t.Run("ReportsNothingForKnownFields", func(t *testing.T) {
+
+		schemaDrift := NewSchemaDrift()
+		var build contracts.Build
+
+		// act
+		err := schemaDrift.Check("/api/pipelines/{source}/{owner}/{repo}/builds/{id}", "/api/pipelines/github.com/estafette/fake-pipeline-1/builds/1001", []byte(`{"id": "1001", "repoName": "fake-pipeline-1"}`), &build)
+
+		assert.Nil(t, err)
+		assert.True(t, schemaDrift.IsEmpty())
+	})
+}
+
+func TestSchemaDriftRestore(t *testing.T) {
+	t.Run("AddsObfuscatedUnknownFieldsToItemsWithTheSameId", func(t *testing.T) {
+
+		schemaDrift := NewSchemaDrift()
+		var response PipelineBuildsListResponse
+		err := json.Unmarshal([]byte(schemaDriftBuildsResponse), &response)
+		assert.Nil(t, err)
+		err = schemaDrift.Check("/api/pipelines/{source}/{owner}/{repo}/builds", "/api/pipelines/github.com/estafette/fake-pipeline-1/builds?page[size]=20", []byte(schemaDriftBuildsResponse), &response)
+		assert.Nil(t, err)
+		obfuscator := newTestObfuscator(t)
+		for _, b := range response.Items {
+			obfuscator.Build(b)
+		}
+		data, err := json.Marshal(response)
+		assert.Nil(t, err)
+
+		// act
+		restored, err := schemaDrift.Restore("/api/pipelines/github.com/estafette/fake-pipeline-1/builds", response, data, obfuscator.Value)
+
+		assert.Nil(t, err)
+		assert.Contains(t, string(restored), `"queuedAt": "2021-01-01T00:00:00Z"`)
+		assert.Contains(t, string(restored), `"avatar": "https://avatars/`+obfuscatedEmail+`"`)
+		assert.Contains(t, string(restored), `"facets"`)
+		assert.NotContains(t, string(restored), "jane@example.com")
+	})
+
+	t.Run("ReturnsDataUnchangedForPathWithoutUnknownFields", func(t *testing.T) {
+
+		schemaDrift := NewSchemaDrift()
+		data := []byte(`{"id":"1001"}`)
+
+		// act
+		restored, err := schemaDrift.Restore("/api/pipelines/github.com/estafette/fake-pipeline-1/builds/1001", contracts.Build{ID: "1001"}, data, newTestObfuscator(t).Value)
+
+		assert.Nil(t, err)
+		assert.Equal(t, data, restored)
+	})
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sinkTypeMocker    = "mocker"
+	sinkTypeDirectory = "directory"
+	sinkTypeTarGz     = "tar.gz"
+	sinkTypeZip       = "zip"
+)
+
+// FixtureSink stores obfuscated responses as fixtures
+type FixtureSink interface {
+	// WriteJSON stores the json response for path, or a single page of it if query holds page[number] and page[size]
+	WriteJSON(path string, query url.Values, bytes []byte) error
+	// WriteSSE stores the server-sent events response for path
+	WriteSSE(path string, bytes []byte) error
+	// WriteFile stores a plain file by its slash separated name next to the fixtures, without serving it as response
+	WriteFile(name string, bytes []byte) error
+	// Finalize flushes and closes the sink once all fixtures have been written
+	Finalize() error
+}
+
+// newFixtureSink returns the sink of sinkType storing fixtures at location; archives get the extension appended to location
+func newFixtureSink(sinkType, location string, handlerOptions map[string]HandlerOptions) (FixtureSink, error) {
+	switch sinkType {
+	case sinkTypeMocker:
+		return NewMockerSink(location, handlerOptions)
+	case sinkTypeDirectory:
+		return NewDirectorySink(location), nil
+	case sinkTypeTarGz:
+		return NewTarGzSink(filepath.Clean(location)+".tar.gz", handlerOptions)
+	case sinkTypeZip:
+		return NewZipSink(filepath.Clean(location)+".zip", handlerOptions)
+	}
+
+	return nil, fmt.Errorf("Sink type %v is not supported", sinkType)
+}
+
+// NewMockerSink returns a sink storing fixtures in directory in the layout connect-api-mocker expects, with a GET.js handler next to each response
+func NewMockerSink(directory string, handlerOptions map[string]HandlerOptions) (FixtureSink, error) {
+	handlers, err := renderMockerHandlers(handlerOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fixtureSink{
+		store:    &directoryStore{directory: directory},
+		handlers: handlers,
+	}, nil
+}
+
+// NewDirectorySink returns a sink storing fixtures in directory as plain json files
+func NewDirectorySink(directory string) FixtureSink {
+	return &fixtureSink{
+		store: &directoryStore{directory: directory},
+	}
+}
+
+// NewTarGzSink returns a sink storing fixtures in the connect-api-mocker layout in a tar.gz archive at path
+func NewTarGzSink(path string, handlerOptions map[string]HandlerOptions) (FixtureSink, error) {
+	handlers, err := renderMockerHandlers(handlerOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fixtureSink{
+		store:    &tarGzStore{path: path, memoryStore: newMemoryStore()},
+		handlers: handlers,
+	}, nil
+}
+
+// NewZipSink returns a sink storing fixtures in the connect-api-mocker layout in a zip archive at path
+func NewZipSink(path string, handlerOptions map[string]HandlerOptions) (FixtureSink, error) {
+	handlers, err := renderMockerHandlers(handlerOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fixtureSink{
+		store:    &zipStore{path: path, memoryStore: newMemoryStore()},
+		handlers: handlers,
+	}, nil
+}
+
+// MemorySink keeps fixtures in memory as plain json files, for embedding and testing
+type MemorySink struct {
+	fixtureSink
+	memory *memoryStore
+}
+
+// NewMemorySink returns a sink keeping fixtures in memory
+func NewMemorySink() *MemorySink {
+	memory := newMemoryStore()
+	return &MemorySink{
+		fixtureSink: fixtureSink{store: memory},
+		memory:      memory,
+	}
+}
+
+// Files returns a copy of the stored files by their slash separated path
+func (s *MemorySink) Files() map[string][]byte {
+	return s.memory.copyFiles()
+}
+
+// fixtureStore writes files by their slash separated path
+type fixtureStore interface {
+	writeFile(name string, bytes []byte) error
+	close() error
+}
+
+// fixtureSink lays out fixtures in a fixtureStore, adding connect-api-mocker handlers if set
+type fixtureSink struct {
+	store    fixtureStore
+	handlers *mockerHandlers
+}
+
+func (s *fixtureSink) WriteJSON(path string, query url.Values, bytes []byte) error {
+
+	if isPageQuery(query) {
+		err := s.store.writeFile(fixtureFileName(path, query), bytes)
+		if err != nil || s.handlers == nil {
+			return err
+		}
+
+		// replace GET.js with one that routes on the page[number] and page[size] query parameters
+		return s.store.writeFile(joinFixturePath(path, "GET.js"), s.handlers.paged)
+	}
+
+	err := s.store.writeFile(fixtureFileName(path, nil), bytes)
+	if err != nil || s.handlers == nil {
+		return err
+	}
+
+	return s.store.writeFile(joinFixturePath(path, "GET.js"), s.handlers.handlers[endpointClass(path, query)])
+}
+
+func (s *fixtureSink) WriteSSE(path string, bytes []byte) error {
+
+	err := s.store.writeFile(fixtureFileName(path, nil), bytes)
+	if err != nil || s.handlers == nil {
+		return err
+	}
+
+	return s.store.writeFile(joinFixturePath(path, "GET.js"), s.handlers.handlers[endpointClassStreams])
+}
+
+func (s *fixtureSink) WriteFile(name string, bytes []byte) error {
+	return s.store.writeFile(name, bytes)
+}
+
+func (s *fixtureSink) Finalize() error {
+	return s.store.close()
+}
+
+// fixtureFileName returns the slash separated name of the file storing the response for path and query
+func fixtureFileName(path string, query url.Values) string {
+	if isPageQuery(query) {
+		pageNumber, _ := strconv.Atoi(query.Get("page[number]"))
+		pageSize, _ := strconv.Atoi(query.Get("page[size]"))
+		return joinFixturePath(path, pageFileName(pageNumber, pageSize))
+	}
+
+	return joinFixturePath(path, "index.json")
+}
+
+func joinFixturePath(path, name string) string {
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Join(path, name)), "/")
+}
+
+// directoryStore writes files to a directory on disk
+type directoryStore struct {
+	directory string
+}
+
+func (s *directoryStore) writeFile(name string, bytes []byte) (err error) {
+	targetPath := filepath.Join(s.directory, filepath.FromSlash(name))
+
+	err = os.MkdirAll(filepath.Dir(targetPath), os.ModePerm)
+	if err != nil {
+		return
+	}
+
+	return ioutil.WriteFile(targetPath, bytes, 0644)
+}
+
+func (s *directoryStore) close() error {
+	return nil
+}
+
+// memoryStore keeps files in memory; overwriting a file replaces it
+type memoryStore struct {
+	mutex sync.Mutex
+	files map[string][]byte
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		files: map[string][]byte{},
+	}
+}
+
+func (s *memoryStore) writeFile(name string, bytes []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.files[name] = append([]byte{}, bytes...)
+
+	return nil
+}
+
+func (s *memoryStore) close() error {
+	return nil
+}
+
+func (s *memoryStore) copyFiles() map[string][]byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	files := make(map[string][]byte, len(s.files))
+	for name, bytes := range s.files {
+		files[name] = bytes
+	}
+
+	return files
+}
+
+func (s *memoryStore) sortedNames() []string {
+	names := make([]string, 0, len(s.files))
+	for name := range s.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// tarGzStore collects files in memory, since handlers get overwritten, and writes them to a tar.gz archive on close
+type tarGzStore struct {
+	*memoryStore
+	path string
+}
+
+func (s *tarGzStore) close() (err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := os.Create(s.path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	modTime := time.Now()
+	for _, name := range s.sortedNames() {
+		err = tarWriter.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(s.files[name])),
+			ModTime: modTime,
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = tarWriter.Write(s.files[name])
+		if err != nil {
+			return
+		}
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return
+	}
+
+	err = gzipWriter.Close()
+	if err != nil {
+		return
+	}
+
+	return file.Close()
+}
+
+// zipStore collects files in memory, since handlers get overwritten, and writes them to a zip archive on close
+type zipStore struct {
+	*memoryStore
+	path string
+}
+
+func (s *zipStore) close() (err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := os.Create(s.path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	zipWriter := zip.NewWriter(file)
+
+	for _, name := range s.sortedNames() {
+		writer, err := zipWriter.Create(name)
+		if err != nil {
+			return err
+		}
+
+		_, err = writer.Write(s.files[name])
+		if err != nil {
+			return err
+		}
+	}
+
+	err = zipWriter.Close()
+	if err != nil {
+		return
+	}
+
+	return file.Close()
+}
+
+// multiSink writes fixtures to several sinks at once
+type multiSink []FixtureSink
+
+func (s multiSink) WriteJSON(path string, query url.Values, bytes []byte) error {
+	for _, sink := range s {
+		err := sink.WriteJSON(path, query, bytes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s multiSink) WriteSSE(path string, bytes []byte) error {
+	for _, sink := range s {
+		err := sink.WriteSSE(path, bytes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s multiSink) WriteFile(name string, bytes []byte) error {
+	for _, sink := range s {
+		err := sink.WriteFile(name, bytes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s multiSink) Finalize() error {
+	for _, sink := range s {
+		err := sink.Finalize()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemorySink(t *testing.T) {
+	t.Run("StoresListAndPagesAsPlainFiles", func(t *testing.T) {
+
+		sink := NewMemorySink()
+
+		// act
+		err := sink.WriteJSON("/api/pipelines", nil, []byte(`{"items":[1,2]}`))
+		assert.Nil(t, err)
+		err = sink.WriteJSON("/api/pipelines", pageQuery(2, 1), []byte(`{"items":[2]}`))
+		assert.Nil(t, err)
+		err = sink.WriteSSE("/api/pipelines/github.com/estafette/estafette-ci-api/builds/5/logs.stream", []byte("event:log\ndata:true\n\n"))
+		assert.Nil(t, err)
+
+		files := sink.Files()
+		assert.Equal(t, 3, len(files))
+		assert.Equal(t, `{"items":[1,2]}`, string(files["api/pipelines/index.json"]))
+		assert.Equal(t, `{"items":[2]}`, string(files["api/pipelines/page-2-size-1.json"]))
+		assert.Equal(t, "event:log\ndata:true\n\n", string(files["api/pipelines/github.com/estafette/estafette-ci-api/builds/5/logs.stream/index.json"]))
+	})
+}
+
+func TestMockerSink(t *testing.T) {
+	t.Run("StoresHandlerNextToResponse", func(t *testing.T) {
+
+		directory, err := ioutil.TempDir("", "mocks")
+		assert.Nil(t, err)
+		defer os.RemoveAll(directory)
+
+		sink, err := NewMockerSink(directory, DefaultHandlerOptions())
+		assert.Nil(t, err)
+
+		// act
+		err = sink.WriteJSON("/api/pipelines", nil, []byte(`{"items":[]}`))
+
+		assert.Nil(t, err)
+		handler, err := ioutil.ReadFile(filepath.Join(directory, "api/pipelines/GET.js"))
+		assert.Nil(t, err)
+		assert.Contains(t, string(handler), "application/json")
+	})
+}
+
+func TestArchiveSinks(t *testing.T) {
+	t.Run("TarGzContainsEachFileOnce", func(t *testing.T) {
+
+		directory, err := ioutil.TempDir("", "mocks")
+		assert.Nil(t, err)
+		defer os.RemoveAll(directory)
+		path := filepath.Join(directory, "mocks.tar.gz")
+
+		sink, err := NewTarGzSink(path, DefaultHandlerOptions())
+		assert.Nil(t, err)
+		err = sink.WriteJSON("/api/pipelines", nil, []byte(`{"items":[]}`))
+		assert.Nil(t, err)
+		err = sink.WriteJSON("/api/pipelines", pageQuery(1, 5), []byte(`{"items":[]}`))
+		assert.Nil(t, err)
+
+		// act
+		err = sink.Finalize()
+
+		assert.Nil(t, err)
+		file, err := os.Open(path)
+		assert.Nil(t, err)
+		defer file.Close()
+		gzipReader, err := gzip.NewReader(file)
+		assert.Nil(t, err)
+		tarReader := tar.NewReader(gzipReader)
+		names := []string{}
+		for {
+			header, err := tarReader.Next()
+			if err != nil {
+				break
+			}
+			names = append(names, header.Name)
+		}
+		assert.Equal(t, []string{"api/pipelines/GET.js", "api/pipelines/index.json", "api/pipelines/page-1-size-5.json"}, names)
+	})
+
+	t.Run("ZipContainsEachFileOnce", func(t *testing.T) {
+
+		directory, err := ioutil.TempDir("", "mocks")
+		assert.Nil(t, err)
+		defer os.RemoveAll(directory)
+		path := filepath.Join(directory, "mocks.zip")
+
+		sink, err := NewZipSink(path, DefaultHandlerOptions())
+		assert.Nil(t, err)
+		err = sink.WriteJSON("/api/pipelines", nil, []byte(`{"items":[]}`))
+		assert.Nil(t, err)
+		err = sink.WriteJSON("/api/pipelines", nil, []byte(`{"items":[1]}`))
+		assert.Nil(t, err)
+
+		// act
+		err = sink.Finalize()
+
+		assert.Nil(t, err)
+		reader, err := zip.OpenReader(path)
+		assert.Nil(t, err)
+		defer reader.Close()
+		assert.Equal(t, 2, len(reader.File))
+	})
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// stagingSink writes fixtures into a staging directory and only swaps it into place once all of them are written and verified;
+// the swap takes two renames, so an interrupted one leaves only the backup, which the next run restores
+type stagingSink struct {
+	sink             FixtureSink
+	directory        string
+	stagingDirectory string
+	backupDirectory  string
+}
+
+// NewStagingSink returns a sink writing to a staging directory next to directory using newSink, which replaces directory on Finalize while keeping the previous content as backup
+func NewStagingSink(directory string, newSink func(directory string) (FixtureSink, error)) (FixtureSink, error) {
+
+	directory = filepath.Clean(directory)
+	stagingDirectory := directory + ".staging"
+	backupDirectory := directory + ".backup"
+
+	err := restoreInterruptedSwap(directory, backupDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	// clear leftovers of a failed run
+	err = os.RemoveAll(stagingDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	sink, err := newSink(stagingDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stagingSink{
+		sink:             sink,
+		directory:        directory,
+		stagingDirectory: stagingDirectory,
+		backupDirectory:  backupDirectory,
+	}, nil
+}
+
+// restoreInterruptedSwap moves the backup back to directory if a previous run stopped between moving directory away and moving staging in its place
+func restoreInterruptedSwap(directory, backupDirectory string) error {
+
+	_, err := os.Stat(directory)
+	if err == nil || !os.IsNotExist(err) {
+		return err
+	}
+
+	_, err = os.Stat(backupDirectory)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = os.Rename(backupDirectory, directory)
+	if err != nil {
+		return err
+	}
+
+	log.Warn().Msgf("Restored %v from %v, left behind by an interrupted swap", directory, backupDirectory)
+
+	return nil
+}
+
+func (s *stagingSink) WriteJSON(path string, query url.Values, bytes []byte) error {
+	return s.sink.WriteJSON(path, query, bytes)
+}
+
+func (s *stagingSink) WriteSSE(path string, bytes []byte) error {
+	return s.sink.WriteSSE(path, bytes)
+}
+
+func (s *stagingSink) WriteFile(name string, bytes []byte) error {
+	return s.sink.WriteFile(name, bytes)
+}
+
+func (s *stagingSink) Finalize() (err error) {
+
+	err = s.sink.Finalize()
+	if err != nil {
+		return
+	}
+
+	err = verifyFixtureDirectory(s.stagingDirectory)
+	if err != nil {
+		return fmt.Errorf("Verification of %v failed, keeping %v untouched: %w", s.stagingDirectory, s.directory, err)
+	}
+
+	return s.swap()
+}
+
+// swap moves the current directory to the backup location and the staging directory in its place, restoring the backup if the latter fails;
+// it isn't atomic, in between there's no export at directory
+func (s *stagingSink) swap() (err error) {
+
+	err = os.RemoveAll(s.backupDirectory)
+	if err != nil {
+		return
+	}
+
+	hasPrevious := true
+	err = os.Rename(s.directory, s.backupDirectory)
+	if os.IsNotExist(err) {
+		hasPrevious = false
+	} else if err != nil {
+		return
+	}
+
+	err = os.Rename(s.stagingDirectory, s.directory)
+	if err != nil {
+		if hasPrevious {
+			restoreErr := os.Rename(s.backupDirectory, s.directory)
+			if restoreErr != nil {
+				log.Error().Err(restoreErr).Msgf("Failed restoring %v from %v", s.directory, s.backupDirectory)
+			}
+		}
+		return
+	}
+
+	log.Info().Msgf("Swapped %v into %v, previous export is kept in %v", s.stagingDirectory, s.directory, s.backupDirectory)
+
+	return nil
+}
+
+// verifyFixtureDirectory checks the pipelines list got stored, all json responses can be parsed and match the manifest if there is one
+func verifyFixtureDirectory(directory string) error {
+
+	_, err := os.Stat(filepath.Join(directory, "api", "pipelines", "index.json"))
+	if err != nil {
+		return err
+	}
+
+	err = verifyManifest(directory)
+	if err != nil {
+		return err
+	}
+
+	return filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".json" || strings.HasSuffix(filepath.Dir(path), ".stream") {
+			return nil
+		}
+
+		bytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !json.Valid(bytes) {
+			return fmt.Errorf("%v is not valid json", path)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStagingSink(t *testing.T) {
+	t.Run("SwapsStagingDirectoryIntoPlaceAndKeepsBackup", func(t *testing.T) {
+
+		parent, err := ioutil.TempDir("", "staging")
+		assert.Nil(t, err)
+		defer os.RemoveAll(parent)
+		directory := filepath.Join(parent, "mocks")
+		err = NewDirectorySink(directory).WriteJSON("/api/pipelines", nil, []byte(`{"items":[1]}`))
+		assert.Nil(t, err)
+
+		sink, err := NewStagingSink(directory, func(directory string) (FixtureSink, error) { return NewDirectorySink(directory), nil })
+		assert.Nil(t, err)
+		err = sink.WriteJSON("/api/pipelines", nil, []byte(`{"items":[2]}`))
+		assert.Nil(t, err)
+
+		// act
+		err = sink.Finalize()
+
+		assert.Nil(t, err)
+		current, err := ioutil.ReadFile(filepath.Join(directory, "api/pipelines/index.json"))
+		assert.Nil(t, err)
+		assert.Equal(t, `{"items":[2]}`, string(current))
+		backup, err := ioutil.ReadFile(filepath.Join(parent, "mocks.backup", "api/pipelines/index.json"))
+		assert.Nil(t, err)
+		assert.Equal(t, `{"items":[1]}`, string(backup))
+		_, err = os.Stat(filepath.Join(parent, "mocks.staging"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("KeepsDirectoryUntouchedIfVerificationFails", func(t *testing.T) {
+
+		parent, err := ioutil.TempDir("", "staging")
+		assert.Nil(t, err)
+		defer os.RemoveAll(parent)
+		directory := filepath.Join(parent, "mocks")
+		err = NewDirectorySink(directory).WriteJSON("/api/pipelines", nil, []byte(`{"items":[1]}`))
+		assert.Nil(t, err)
+
+		sink, err := NewStagingSink(directory, func(directory string) (FixtureSink, error) { return NewDirectorySink(directory), nil })
+		assert.Nil(t, err)
+		err = sink.WriteJSON("/api/pipelines", nil, []byte(`{"items":[`))
+		assert.Nil(t, err)
+
+		// act
+		err = sink.Finalize()
+
+		assert.NotNil(t, err)
+		current, err := ioutil.ReadFile(filepath.Join(directory, "api/pipelines/index.json"))
+		assert.Nil(t, err)
+		assert.Equal(t, `{"items":[1]}`, string(current))
+	})
+
+	t.Run("RestoresBackupLeftByInterruptedSwap", func(t *testing.T) {
+
+		parent, err := ioutil.TempDir("", "staging")
+		assert.Nil(t, err)
+		defer os.RemoveAll(parent)
+		directory := filepath.Join(parent, "mocks")
+		err = NewDirectorySink(filepath.Join(parent, "mocks.backup")).WriteJSON("/api/pipelines", nil, []byte(`{"items":[1]}`))
+		assert.Nil(t, err)
+
+		// act
+		_, err = NewStagingSink(directory, func(directory string) (FixtureSink, error) { return NewDirectorySink(directory), nil })
+
+		assert.Nil(t, err)
+		current, err := ioutil.ReadFile(filepath.Join(directory, "api/pipelines/index.json"))
+		assert.Nil(t, err)
+		assert.Equal(t, `{"items":[1]}`, string(current))
+		_, err = os.Stat(filepath.Join(parent, "mocks.backup"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// see http://wiremock.org/docs/stubbing/ for the mapping format
+
+type wireMockMapping struct {
+	Priority int              `json:"priority,omitempty"`
+	Request  wireMockRequest  `json:"request"`
+	Response wireMockResponse `json:"response"`
+}
+
+type wireMockRequest struct {
+	Method          string                          `json:"method"`
+	URLPath         string                          `json:"urlPath"`
+	QueryParameters map[string]wireMockValueMatcher `json:"queryParameters,omitempty"`
+}
+
+type wireMockValueMatcher struct {
+	EqualTo string `json:"equalTo"`
+}
+
+type wireMockResponse struct {
+	Status       int               `json:"status"`
+	Headers      map[string]string `json:"headers"`
+	BodyFileName string            `json:"bodyFileName"`
+}
+
+// NewWireMockSink returns a sink storing responses as WireMock mappings and body files in directory
+func NewWireMockSink(directory string) FixtureSink {
+	return &wireMockSink{
+		directory: directory,
+	}
+}
+
+type wireMockSink struct {
+	directory string
+}
+
+func (s *wireMockSink) WriteJSON(path string, query url.Values, bytes []byte) error {
+	return saveWireMockMapping(s.directory, path, query, "application/json", bytes)
+}
+
+func (s *wireMockSink) WriteSSE(path string, bytes []byte) error {
+	return saveWireMockMapping(s.directory, path, nil, "text/event-stream", bytes)
+}
+
+// WriteFile ignores plain files, WireMock only serves mappings
+func (s *wireMockSink) WriteFile(name string, bytes []byte) error {
+	return nil
+}
+
+func (s *wireMockSink) Finalize() error {
+	return nil
+}
+
+// saveWireMockMapping stores the response for path and query as body file in __files and a mapping matching the request in mappings,
+// both named after a hash of path and query
+func saveWireMockMapping(directory, path string, query url.Values, contentType string, bytes []byte) (err error) {
+
+	name := wireMockFileName(path, query)
+	bodyFileName := name + ".json"
+
+	bodyFilePath := filepath.Join(directory, "__files", filepath.FromSlash(bodyFileName))
+	err = os.MkdirAll(filepath.Dir(bodyFilePath), os.ModePerm)
+	if err != nil {
+		return
+	}
+
+	err = ioutil.WriteFile(bodyFilePath, bytes, 0644)
+	if err != nil {
+		return
+	}
+
+	mapping := wireMockMapping{
+		Request: wireMockRequest{
+			Method:  http.MethodGet,
+			URLPath: path,
+		},
+		Response: wireMockResponse{
+			Status: http.StatusOK,
+			Headers: map[string]string{
+				"Content-Type": contentType,
+			},
+			BodyFileName: bodyFileName,
+		},
+	}
+
+	// mappings matching on query parameters take precedence over the one for the full list
+	if len(query) > 0 {
+		mapping.Priority = 1
+		mapping.Request.QueryParameters = map[string]wireMockValueMatcher{}
+		for k := range query {
+			mapping.Request.QueryParameters[k] = wireMockValueMatcher{EqualTo: query.Get(k)}
+		}
+	} else {
+		mapping.Priority = 5
+	}
+
+	mappingBytes, err := json.MarshalIndent(mapping, "", "  ")
+	if err != nil {
+		return
+	}
+
+	mappingsDir := filepath.Join(directory, "mappings")
+	err = os.MkdirAll(mappingsDir, os.ModePerm)
+	if err != nil {
+		return
+	}
+
+	return ioutil.WriteFile(filepath.Join(mappingsDir, name+".json"), mappingBytes, 0644)
+}
+
+// wireMockFileName returns the name of the mapping and body file for path and query, a hash of both so no two requests share
+// a file, whatever characters their paths and query parameters hold
+func wireMockFileName(path string, query url.Values) string {
+	sum := sha256.Sum256([]byte(path + "?" + query.Encode()))
+	return hex.EncodeToString(sum[:16])
+}
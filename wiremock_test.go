@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveWireMockMapping(t *testing.T) {
+	t.Run("SavesMappingWithQueryParameterMatchersForPage", func(t *testing.T) {
+
+		directory, err := ioutil.TempDir("", "wiremock")
+		assert.Nil(t, err)
+		defer os.RemoveAll(directory)
+
+		// act
+		err = saveWireMockMapping(directory, "/api/pipelines/github.com/estafette/estafette-ci-api/builds", pageQuery(2, 5), "application/json", []byte(`{"items":[]}`))
+
+		assert.Nil(t, err)
+		bytes, err := ioutil.ReadFile(filepath.Join(directory, "mappings", wireMockFileName("/api/pipelines/github.com/estafette/estafette-ci-api/builds", pageQuery(2, 5))+".json"))
+		assert.Nil(t, err)
+		var mapping wireMockMapping
+		err = json.Unmarshal(bytes, &mapping)
+		assert.Nil(t, err)
+		assert.Equal(t, "/api/pipelines/github.com/estafette/estafette-ci-api/builds", mapping.Request.URLPath)
+		assert.Equal(t, "2", mapping.Request.QueryParameters["page[number]"].EqualTo)
+		assert.Equal(t, "5", mapping.Request.QueryParameters["page[size]"].EqualTo)
+		assert.Equal(t, 1, mapping.Priority)
+		body, err := ioutil.ReadFile(filepath.Join(directory, "__files", filepath.FromSlash(mapping.Response.BodyFileName)))
+		assert.Nil(t, err)
+		assert.Equal(t, `{"items":[]}`, string(body))
+	})
+
+	t.Run("SavesSeparateMappingsForPathsThatOnlyDifferInSlashes", func(t *testing.T) {
+
+		directory, err := ioutil.TempDir("", "wiremock")
+		assert.Nil(t, err)
+		defer os.RemoveAll(directory)
+		err = saveWireMockMapping(directory, "/api/pipelines/github.com/estafette/estafette-ci-api", nil, "application/json", []byte(`{"id":"1"}`))
+		assert.Nil(t, err)
+
+		// act
+		err = saveWireMockMapping(directory, "/api/pipelines/github.com/estafette_estafette-ci-api", nil, "application/json", []byte(`{"id":"2"}`))
+
+		assert.Nil(t, err)
+		mappings, err := ioutil.ReadDir(filepath.Join(directory, "mappings"))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(mappings))
+		bodies, err := ioutil.ReadDir(filepath.Join(directory, "__files"))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(bodies))
+	})
+}